@@ -0,0 +1,219 @@
+package justlog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type DropPolicy uint8
+
+const (
+	DropPolicyBlock DropPolicy = iota
+	DropPolicyNewest
+	DropPolicyOldest
+)
+
+const DefaultAsyncReportInterval = time.Second
+
+var ErrAsyncWriterClosed = errors.New("async writer closed")
+
+func ParseDropPolicy(strPolicy string) (DropPolicy, error) {
+	switch strPolicy {
+	case "block", "":
+		return DropPolicyBlock, nil
+	case "drop-newest":
+		return DropPolicyNewest, nil
+	case "drop-oldest":
+		return DropPolicyOldest, nil
+	}
+	return DropPolicyBlock, fmt.Errorf("invalid drop policy value: %q", strPolicy)
+}
+
+// AsyncWriter queues copies of written lines and writes them to the
+// underlying writer from a single goroutine. Each Write is expected to be one
+// complete log line.
+type AsyncWriter struct {
+	// DropReport formats a line telling how many lines were dropped. It is
+	// called from the writer goroutine and its result bypasses the queue.
+	DropReport func(dropped uint64) []byte
+	// ErrorFunc receives errors of the output, which has no caller to return
+	// them to. It is called from the writer goroutine.
+	ErrorFunc func(err error)
+
+	policy   DropPolicy
+	queue    chan []byte
+	flushReq chan chan struct{}
+	done     chan struct{}
+	dropped  uint64
+
+	outMu sync.Mutex
+	out   io.Writer
+
+	closeMu sync.RWMutex
+	closed  bool
+}
+
+func NewAsyncWriter(out io.Writer, queueSize int, policy DropPolicy, reportInterval time.Duration) *AsyncWriter {
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	if reportInterval <= 0 {
+		reportInterval = DefaultAsyncReportInterval
+	}
+	w := &AsyncWriter{
+		policy:   policy,
+		queue:    make(chan []byte, queueSize),
+		flushReq: make(chan chan struct{}),
+		done:     make(chan struct{}),
+		out:      out,
+	}
+	go w.run(reportInterval)
+	return w
+}
+
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	w.closeMu.RLock()
+	defer w.closeMu.RUnlock()
+	if w.closed {
+		return 0, ErrAsyncWriterClosed
+	}
+
+	line := append([]byte(nil), p...)
+	switch w.policy {
+	case DropPolicyNewest:
+		select {
+		case w.queue <- line:
+		default:
+			atomic.AddUint64(&w.dropped, 1)
+		}
+	case DropPolicyOldest:
+		for {
+			select {
+			case w.queue <- line:
+				return len(p), nil
+			default:
+			}
+			select {
+			case <-w.queue:
+				atomic.AddUint64(&w.dropped, 1)
+			default:
+			}
+		}
+	default:
+		w.queue <- line
+	}
+	return len(p), nil
+}
+
+// Dropped returns number of lines dropped and not yet reported.
+func (w *AsyncWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+func (w *AsyncWriter) SetOutput(out io.Writer) {
+	w.outMu.Lock()
+	defer w.outMu.Unlock()
+	w.out = out
+}
+
+// Reopen passes the call to the underlying writer if it is a Reopener.
+func (w *AsyncWriter) Reopen() error {
+	w.outMu.Lock()
+	defer w.outMu.Unlock()
+	if reopener, ok := w.out.(Reopener); ok {
+		return reopener.Reopen()
+	}
+	return nil
+}
+
+// Flush returns after every line queued before the call is written.
+func (w *AsyncWriter) Flush() error {
+	w.closeMu.RLock()
+	defer w.closeMu.RUnlock()
+	if w.closed {
+		return ErrAsyncWriterClosed
+	}
+
+	ack := make(chan struct{})
+	w.flushReq <- ack
+	<-ack
+	return nil
+}
+
+// Close writes out the queue, stops the writer goroutine and closes the
+// underlying writer if it is closable.
+func (w *AsyncWriter) Close() error {
+	w.closeMu.Lock()
+	if w.closed {
+		w.closeMu.Unlock()
+		return ErrAsyncWriterClosed
+	}
+	w.closed = true
+	close(w.queue)
+	w.closeMu.Unlock()
+
+	<-w.done
+
+	w.outMu.Lock()
+	defer w.outMu.Unlock()
+	return closeOutput(w.out)
+}
+
+func (w *AsyncWriter) run(reportInterval time.Duration) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(reportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case line, ok := <-w.queue:
+			if !ok {
+				w.reportDropped()
+				return
+			}
+			w.write(line)
+		case <-ticker.C:
+			w.reportDropped()
+		case ack := <-w.flushReq:
+			w.drain()
+			w.reportDropped()
+			close(ack)
+		}
+	}
+}
+
+func (w *AsyncWriter) drain() {
+	for {
+		select {
+		case line, ok := <-w.queue:
+			if !ok {
+				return
+			}
+			w.write(line)
+		default:
+			return
+		}
+	}
+}
+
+func (w *AsyncWriter) reportDropped() {
+	dropped := atomic.SwapUint64(&w.dropped, 0)
+	if dropped == 0 || w.DropReport == nil {
+		return
+	}
+	w.write(w.DropReport(dropped))
+}
+
+func (w *AsyncWriter) write(line []byte) {
+	w.outMu.Lock()
+	_, err := w.out.Write(line)
+	w.outMu.Unlock()
+	if err != nil && w.ErrorFunc != nil {
+		w.ErrorFunc(err)
+	}
+}
@@ -0,0 +1,227 @@
+package justlog
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// gateWriter blocks every Write until the gate is opened.
+type gateWriter struct {
+	gate    chan struct{}
+	started chan struct{}
+	once    sync.Once
+	mu      sync.Mutex
+	lines   []string
+	closed  bool
+}
+
+func newGateWriter() *gateWriter {
+	return &gateWriter{gate: make(chan struct{}), started: make(chan struct{})}
+}
+
+func (w *gateWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.started) })
+	<-w.gate
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lines = append(w.lines, string(p))
+	return len(p), nil
+}
+
+func (w *gateWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	return nil
+}
+
+func (w *gateWriter) Lines() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.lines...)
+}
+
+type TestCase_AsyncWriter_Drop struct {
+	Policy      DropPolicy
+	QueueSize   int
+	WriteCount  int
+	WantLines   []string
+	WantDropped uint64
+}
+
+func (tc TestCase_AsyncWriter_Drop) Run(t *testing.T) {
+	out := newGateWriter()
+	w := NewAsyncWriter(out, tc.QueueSize, tc.Policy, time.Hour)
+	w.DropReport = func(dropped uint64) []byte {
+		return []byte("dropped " + strconv.FormatUint(dropped, 10))
+	}
+
+	// first line is taken by the writer goroutine and blocks there
+	w.Write([]byte("line0"))
+	<-out.started
+	for i := 1; i < tc.WriteCount; i++ {
+		n, err := w.Write([]byte("line" + strconv.Itoa(i)))
+		assert.NoError(t, err)
+		assert.Equal(t, len("line"+strconv.Itoa(i)), n)
+	}
+	assert.Equal(t, tc.WantDropped, w.Dropped())
+
+	close(out.gate)
+	assert.NoError(t, w.Close())
+	assert.Equal(t, tc.WantLines, out.Lines())
+	assert.True(t, out.closed)
+}
+
+func Test_AsyncWriter_DropNewest(t *testing.T) {
+	TestCase_AsyncWriter_Drop{
+		Policy:      DropPolicyNewest,
+		QueueSize:   2,
+		WriteCount:  6,
+		WantDropped: 3,
+		WantLines:   []string{"line0", "line1", "line2", "dropped 3"},
+	}.Run(t)
+}
+
+func Test_AsyncWriter_DropOldest(t *testing.T) {
+	TestCase_AsyncWriter_Drop{
+		Policy:      DropPolicyOldest,
+		QueueSize:   2,
+		WriteCount:  6,
+		WantDropped: 3,
+		WantLines:   []string{"line0", "line4", "line5", "dropped 3"},
+	}.Run(t)
+}
+
+func Test_AsyncWriter_Block(t *testing.T) {
+	var out strings.Builder
+	w := NewAsyncWriter(&out, 1, DropPolicyBlock, time.Hour)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				w.Write([]byte("x\n"))
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.NoError(t, w.Flush())
+	assert.Equal(t, 400, strings.Count(out.String(), "x\n"))
+	assert.Equal(t, uint64(0), w.Dropped())
+
+	assert.NoError(t, w.Close())
+	_, err := w.Write([]byte("late\n"))
+	assert.Equal(t, ErrAsyncWriterClosed, err)
+	assert.Equal(t, ErrAsyncWriterClosed, w.Flush())
+}
+
+func Test_AsyncWriter_PeriodicReport(t *testing.T) {
+	out := newGateWriter()
+	w := NewAsyncWriter(out, 1, DropPolicyNewest, 10*time.Millisecond)
+	w.DropReport = func(dropped uint64) []byte {
+		return []byte("dropped " + strconv.FormatUint(dropped, 10))
+	}
+
+	w.Write([]byte("line0"))
+	<-out.started
+	w.Write([]byte("line1"))
+	w.Write([]byte("line2"))
+	close(out.gate)
+
+	deadline := time.Now().Add(time.Second)
+	for len(out.Lines()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	assert.Equal(t, []string{"line0", "line1", "dropped 1"}, out.Lines())
+	assert.NoError(t, w.Close())
+}
+
+func Test_FmtBasedLogger_Async(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{
+		ShowNoTime:      true,
+		AsyncQueueSize:  16,
+		AsyncDropPolicy: "drop-oldest",
+	})
+	assert.NoError(t, err)
+
+	out := newGateWriter()
+	close(out.gate)
+	logger.SetOutput(out)
+
+	logger.Info("first")
+	logger.WithField("k", 1).Warn("second")
+	assert.NoError(t, logger.Flush())
+
+	lines := out.Lines()
+	if assert.Len(t, lines, 2) {
+		assert.Regexp(t, `\[INF\] first\n$`, lines[0])
+		assert.Regexp(t, `\[WRN\] second k=1\n$`, lines[1])
+	}
+
+	assert.NoError(t, logger.Close())
+	assert.True(t, out.closed)
+}
+
+func Test_FmtBasedLogger_AsyncDropReport(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{ShowNoTime: true})
+	assert.NoError(t, err)
+	assert.Regexp(t, `^\[\+0\.000000\] \[WRN\] async log queue full, dropped 7 messages\n$`, string(logger.dropReport(7)))
+}
+
+func Test_NewFmtBasedLogger_InvalidDropPolicy(t *testing.T) {
+	_, err := NewFmtBasedLogger(LoggerConfig{AsyncQueueSize: 1, AsyncDropPolicy: "drop-all"})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `"drop-all"`)
+	}
+}
+
+func Test_FmtBasedLogger_Fatal_AsyncFlush(t *testing.T) {
+	patches := gomonkey.NewPatches()
+	defer patches.Reset()
+
+	out := newGateWriter()
+	var linesAtExit []string
+	patches.ApplyFunc(os.Exit, func(code int) {
+		assert.Equal(t, 1, code)
+		linesAtExit = out.Lines()
+	})
+
+	logger, err := NewFmtBasedLogger(LoggerConfig{ShowNoTime: true, DeltaMode: "none", AsyncQueueSize: 10})
+	assert.NoError(t, err)
+	logger.SetOutput(out)
+	time.AfterFunc(50*time.Millisecond, func() { close(out.gate) })
+	logger.WithField("k", "v").Fatalf("fatal %s", "error")
+
+	assert.Equal(t, []string{"[ERR][FATAL] fatal error k=v\n"}, linesAtExit)
+	assert.NoError(t, logger.Close())
+}
+
+func Test_FmtBasedLogger_Async_WriteErrorReported(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{AsyncQueueSize: 10})
+	assert.NoError(t, err)
+	var mu sync.Mutex
+	var errs []string
+	logger.ErrorFunc = func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err.Error())
+	}
+	logger.SetOutput(failingWriter{})
+
+	logger.Info("lost")
+	assert.NoError(t, logger.Flush())
+	mu.Lock()
+	assert.Equal(t, []string{"disk on fire"}, errs)
+	mu.Unlock()
+	assert.NoError(t, logger.Close())
+}
@@ -0,0 +1,66 @@
+package justlog
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	ColorNever  = "never"
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+)
+
+var (
+	ansiReset = []byte("\x1b[0m")
+	ansiDim   = []byte("\x1b[2m")
+
+	ansiLevelTrace = []byte("\x1b[90m")
+	ansiLevelDebug = []byte("\x1b[36m")
+	ansiLevelInfo  = []byte("\x1b[32m")
+	ansiLevelWarn  = []byte("\x1b[33m")
+	ansiLevelError = []byte("\x1b[31m")
+	ansiLevelFatal = []byte("\x1b[1;31m")
+	ansiLevelWTF   = []byte("\x1b[35m")
+)
+
+func ansiLevelColor(lvl Level) []byte {
+	switch lvl {
+	case LogLevelTrace:
+		return ansiLevelTrace
+	case LogLevelDebug:
+		return ansiLevelDebug
+	case LogLevelInfo:
+		return ansiLevelInfo
+	case LogLevelWarn:
+		return ansiLevelWarn
+	case LogLevelError:
+		return ansiLevelError
+	case LogLevelFatal:
+		return ansiLevelFatal
+	}
+	return ansiLevelWTF
+}
+
+func checkColorMode(mode string) error {
+	switch mode {
+	case "", ColorNever, ColorAuto, ColorAlways:
+		return nil
+	}
+	return fmt.Errorf("invalid color mode: %q", mode)
+}
+
+// colorEnabled decides if lines written to out are colored in auto mode.
+// Non-empty FORCE_COLOR turns color on unless set to 0 or false, then
+// non-empty NO_COLOR turns it off, otherwise color is on for terminals only.
+func colorEnabled(out io.Writer) bool {
+	if force := os.Getenv("FORCE_COLOR"); force != "" {
+		return force != "0" && force != "false"
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	file, ok := out.(*os.File)
+	return ok && isTerminal(file.Fd())
+}
@@ -0,0 +1,13 @@
+//go:build linux
+// +build linux
+
+package justlog
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+func isTerminal(fd uintptr) bool {
+	_, err := unix.IoctlGetTermios(int(fd), unix.TCGETS)
+	return err == nil
+}
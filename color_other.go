@@ -0,0 +1,8 @@
+//go:build !linux
+// +build !linux
+
+package justlog
+
+func isTerminal(fd uintptr) bool {
+	return false
+}
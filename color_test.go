@@ -0,0 +1,98 @@
+package justlog
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FmtBasedLogger_Warn_ColorAlways(t *testing.T) {
+	TestCase_Logger{
+		Calls: TestLoggerCalls{
+			{Method: "Warn", Args: []interface{}{"low disk"}},
+		},
+		Config: &LoggerConfig{Color: ColorAlways},
+		TimeSequence: []time.Time{
+			time.Date(2021, time.Month(2), 1, 3, 4, 3, 8000000, time.UTC),
+			time.Date(2021, time.Month(2), 1, 3, 4, 5, 9000000, time.UTC),
+		},
+		WantOutput: "\x1b[2m2021-02-01 03:04:05.009000[+2.001000]\x1b[0m \x1b[33m[WRN]\x1b[0m low disk\n",
+	}.Run(t)
+}
+
+func Test_NewFmtBasedLogger_Color_Invalid(t *testing.T) {
+	_, err := NewFmtBasedLogger(LoggerConfig{Color: "yes"})
+	assert.Error(t, err)
+}
+
+type TestCase_ColorAuto struct {
+	Env       map[string]string
+	WantColor bool
+}
+
+func (tc TestCase_ColorAuto) Run(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{"FORCE_COLOR", "NO_COLOR"} {
+		if value, ok := os.LookupEnv(name); ok {
+			defer os.Setenv(name, value)
+		} else {
+			defer os.Unsetenv(name)
+		}
+		os.Unsetenv(name)
+	}
+	for name, value := range tc.Env {
+		os.Setenv(name, value)
+	}
+
+	logger, err := NewFmtBasedLogger(LoggerConfig{ShowNoTime: true, Color: ColorAuto})
+	assert.NoError(t, err)
+	var out strings.Builder
+	logger.SetOutput(&out)
+	logger.Info("hello")
+	assert.Equal(t, tc.WantColor, strings.Contains(out.String(), "\x1b["), out.String())
+}
+
+func Test_FmtBasedLogger_ColorAuto_NotTerminal(t *testing.T) {
+	TestCase_ColorAuto{WantColor: false}.Run(t)
+}
+
+func Test_FmtBasedLogger_ColorAuto_ForceColor(t *testing.T) {
+	TestCase_ColorAuto{Env: map[string]string{"FORCE_COLOR": "1", "NO_COLOR": "1"}, WantColor: true}.Run(t)
+}
+
+func Test_FmtBasedLogger_ColorAuto_ForceColorZero(t *testing.T) {
+	TestCase_ColorAuto{Env: map[string]string{"FORCE_COLOR": "0"}, WantColor: false}.Run(t)
+}
+
+func Test_FmtBasedLogger_ColorAuto_ForceColorEmpty(t *testing.T) {
+	TestCase_ColorAuto{Env: map[string]string{"FORCE_COLOR": "", "NO_COLOR": "1"}, WantColor: false}.Run(t)
+}
+
+func Test_FmtBasedLogger_ColorAuto_SetOutputConcurrent(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{Color: ColorAuto})
+	assert.NoError(t, err)
+	logger.SetOutput(&lockedBuffer{})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			logger.Info("line")
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		logger.SetOutput(&lockedBuffer{})
+	}
+	<-done
+}
+
+func Test_isTerminal_Pipe(t *testing.T) {
+	r, w, err := os.Pipe()
+	assert.NoError(t, err)
+	defer r.Close()
+	defer w.Close()
+	assert.False(t, isTerminal(w.Fd()))
+}
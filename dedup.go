@@ -0,0 +1,111 @@
+package justlog
+
+import (
+	"bytes"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const DefaultDedupTimeout = 30 * time.Second
+
+// dedupWriter holds back lines repeating the previous one, like syslogd
+// does. The first line is written at once, repeats are only counted. The
+// count is written as "last message repeated N times" when a different line
+// arrives or timeout passes since the first repeat.
+type dedupWriter struct {
+	timeout time.Duration
+
+	mu       sync.Mutex
+	key      []byte
+	scratch  []byte
+	level    Level
+	name     string
+	delta    *DeltaTracker
+	flow     *Flow
+	repeated int
+	lastTime time.Time
+	timer    *time.Timer
+	// gen tells timer callbacks of a finished series to do nothing
+	gen uint64
+}
+
+func newDedupWriter(timeout time.Duration) *dedupWriter {
+	if timeout <= 0 {
+		timeout = DefaultDedupTimeout
+	}
+	return &dedupWriter{timeout: timeout}
+}
+
+// appendDedupKey appends everything that makes lines identical except time.
+func appendDedupKey(buf []byte, rec *Record) []byte {
+	buf = append(buf, rec.Name...)
+	buf = append(buf, 0)
+	buf = append(buf, rec.Message...)
+	buf = append(buf, 0)
+	return appendFieldsText(buf, rec.Fields)
+}
+
+func (dedup *dedupWriter) write(logger *FmtBasedLogger, rec *Record) error {
+	dedup.mu.Lock()
+	defer dedup.mu.Unlock()
+
+	dedup.scratch = appendDedupKey(dedup.scratch[:0], rec)
+	if dedup.key != nil && rec.Level == dedup.level && rec.Flow == dedup.flow && bytes.Equal(dedup.scratch, dedup.key) {
+		dedup.repeated++
+		dedup.lastTime = rec.Time
+		if dedup.timer == nil {
+			gen := dedup.gen
+			dedup.timer = time.AfterFunc(dedup.timeout, func() { dedup.expire(logger, gen) })
+		}
+		return nil
+	}
+
+	err := dedup.flushLocked(logger)
+	dedup.key = append(dedup.key[:0], dedup.scratch...)
+	dedup.level = rec.Level
+	dedup.name = rec.Name
+	dedup.delta = rec.delta
+	dedup.flow = rec.Flow
+	if werr := logger.writeEncoded(rec); werr != nil {
+		err = werr
+	}
+	return err
+}
+
+func (dedup *dedupWriter) expire(logger *FmtBasedLogger, gen uint64) {
+	dedup.mu.Lock()
+	defer dedup.mu.Unlock()
+	if gen == dedup.gen {
+		dedup.flushLocked(logger)
+	}
+}
+
+func (dedup *dedupWriter) flush(logger *FmtBasedLogger) error {
+	dedup.mu.Lock()
+	defer dedup.mu.Unlock()
+	return dedup.flushLocked(logger)
+}
+
+// flushLocked writes the repeat count, if any. The summary line has time
+// of the last repeat, so its delta is the time the series took.
+func (dedup *dedupWriter) flushLocked(logger *FmtBasedLogger) error {
+	if dedup.timer != nil {
+		dedup.timer.Stop()
+		dedup.timer = nil
+	}
+	dedup.gen++
+	if dedup.repeated == 0 {
+		return nil
+	}
+	rec := Record{
+		Time:    dedup.lastTime,
+		Level:   dedup.level,
+		Name:    dedup.name,
+		delta:   dedup.delta,
+		Flow:    dedup.flow,
+		Message: []byte("last message repeated " + strconv.Itoa(dedup.repeated) + " times"),
+	}
+	dedup.repeated = 0
+	return logger.writeEncoded(&rec)
+}
@@ -0,0 +1,93 @@
+package justlog
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FmtBasedLogger_Errorf_Dedup(t *testing.T) {
+	TestCase_Logger{
+		Calls: TestLoggerCalls{
+			{Method: "Errorf", Format: "connect: %s", Args: []interface{}{"refused"}},
+			{Method: "Errorf", Format: "connect: %s", Args: []interface{}{"refused"}},
+			{Method: "Errorf", Format: "connect: %s", Args: []interface{}{"refused"}},
+			{Method: "Errorf", Format: "connect: %s", Args: []interface{}{"refused"}, Fields: Fields{"retry": 3}},
+			{Method: "Info", Args: []interface{}{"connected"}},
+		},
+		Config: &LoggerConfig{Dedup: true},
+		TimeSequence: []time.Time{
+			time.Date(2021, time.Month(2), 1, 3, 4, 3, 0, time.UTC),
+			time.Date(2021, time.Month(2), 1, 3, 4, 5, 0, time.UTC),
+			time.Date(2021, time.Month(2), 1, 3, 4, 6, 0, time.UTC),
+			time.Date(2021, time.Month(2), 1, 3, 4, 8, 0, time.UTC),
+			time.Date(2021, time.Month(2), 1, 3, 4, 9, 0, time.UTC),
+			time.Date(2021, time.Month(2), 1, 3, 4, 10, 0, time.UTC),
+		},
+		WantOutput: "2021-02-01 03:04:05.000000[+2.000000] [ERR] connect: refused\n" +
+			"2021-02-01 03:04:08.000000[+3.000000] [ERR] last message repeated 2 times\n" +
+			"2021-02-01 03:04:09.000000[+1.000000] [ERR] connect: refused retry=3\n" +
+			"2021-02-01 03:04:10.000000[+1.000000] [INF] connected\n",
+	}.Run(t)
+}
+
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func Test_FmtBasedLogger_Dedup_Timeout(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{ShowNoTime: true, Dedup: true, DedupTimeout: 10 * time.Millisecond})
+	assert.NoError(t, err)
+	var out lockedBuffer
+	logger.SetOutput(&out)
+
+	for i := 0; i < 4; i++ {
+		logger.Warn("disk full")
+	}
+	for attempt := 0; attempt < 100 && strings.Count(out.String(), "\n") < 2; attempt++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	logger.Warn("disk full")
+	assert.NoError(t, logger.Flush())
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	want := []string{
+		"[WRN] disk full",
+		"[WRN] last message repeated 3 times",
+		"[WRN] last message repeated 1 times",
+	}
+	if assert.Len(t, lines, len(want), out.String()) {
+		for i := range want {
+			assert.True(t, strings.HasSuffix(lines[i], want[i]), lines[i])
+		}
+	}
+}
+
+func Test_FmtBasedLogger_Dedup_Named(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{ShowNoTime: true, Dedup: true})
+	assert.NoError(t, err)
+	var out strings.Builder
+	logger.SetOutput(&out)
+
+	logger.Named("db").Info("ready")
+	logger.Named("http").Info("ready")
+	logger.Info("ready")
+	assert.Equal(t, 3, strings.Count(out.String(), "\n"), out.String())
+}
@@ -0,0 +1,88 @@
+package justlog
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+type DeltaMode uint8
+
+const (
+	// DeltaGlobal is time since the previous line of any logger.
+	DeltaGlobal DeltaMode = iota
+	// DeltaLogger is time since the previous line of the same logger, each
+	// child made by WithField or Named counts its own lines.
+	DeltaLogger
+	// DeltaStart is time since the process start.
+	DeltaStart
+	// DeltaNone turns the delta column off.
+	DeltaNone
+)
+
+var processStart = time.Now()
+
+func ParseDeltaMode(strMode string) (DeltaMode, error) {
+	switch strMode {
+	case "global", "":
+		return DeltaGlobal, nil
+	case "logger":
+		return DeltaLogger, nil
+	case "start":
+		return DeltaStart, nil
+	case "none":
+		return DeltaNone, nil
+	}
+	return DeltaGlobal, fmt.Errorf("invalid delta mode value: %q", strMode)
+}
+
+// DeltaTracker holds time of the previous line and is safe for concurrent
+// use. Zero value has no previous time, first delta is zero then.
+type DeltaTracker struct {
+	prev int64 // UnixNano, accessed atomically
+}
+
+func NewDeltaTracker(prev time.Time) *DeltaTracker {
+	tracker := &DeltaTracker{}
+	tracker.Set(prev)
+	return tracker
+}
+
+func (tracker *DeltaTracker) Set(prev time.Time) {
+	var prevNano int64
+	if !prev.IsZero() {
+		prevNano = prev.UnixNano()
+	}
+	atomic.StoreInt64(&tracker.prev, prevNano)
+}
+
+func (tracker *DeltaTracker) Prev() time.Time {
+	prev := atomic.LoadInt64(&tracker.prev)
+	if prev == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, prev)
+}
+
+// Swap stores now as the previous time and returns time passed since the
+// one stored before.
+func (tracker *DeltaTracker) Swap(now time.Time) time.Duration {
+	nowNano := now.UnixNano()
+	prev := atomic.SwapInt64(&tracker.prev, nowNano)
+	if prev == 0 {
+		return 0
+	}
+	return time.Duration(nowNano - prev)
+}
+
+// deltaFor returns delta of a line written at now according to mode,
+// tracker is the one of the logger writing the line.
+func deltaFor(mode DeltaMode, tracker *DeltaTracker, now time.Time) time.Duration {
+	switch mode {
+	case DeltaGlobal, DeltaLogger:
+		return tracker.Swap(now)
+	case DeltaStart:
+		return now.Sub(processStart)
+	}
+	return 0
+}
@@ -0,0 +1,132 @@
+package justlog
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseDeltaMode(t *testing.T) {
+	for strMode, want := range map[string]DeltaMode{
+		"":       DeltaGlobal,
+		"global": DeltaGlobal,
+		"logger": DeltaLogger,
+		"start":  DeltaStart,
+		"none":   DeltaNone,
+	} {
+		mode, err := ParseDeltaMode(strMode)
+		assert.NoError(t, err, strMode)
+		assert.Equal(t, want, mode, strMode)
+	}
+	_, err := ParseDeltaMode("local")
+	assert.EqualError(t, err, `invalid delta mode value: "local"`)
+	_, err = NewFmtBasedLogger(LoggerConfig{DeltaMode: "local"})
+	assert.Error(t, err)
+}
+
+func Test_DeltaTracker_Swap(t *testing.T) {
+	start := time.Date(2021, time.Month(2), 1, 3, 4, 5, 0, time.UTC)
+	tracker := &DeltaTracker{}
+	assert.True(t, tracker.Prev().IsZero())
+	assert.Equal(t, time.Duration(0), tracker.Swap(start))
+	assert.Equal(t, 2*time.Second, tracker.Swap(start.Add(2*time.Second)))
+	assert.True(t, start.Add(2*time.Second).Equal(tracker.Prev()))
+	tracker.Set(time.Time{})
+	assert.True(t, tracker.Prev().IsZero())
+}
+
+func deltaTestLines(t *testing.T, cfg LoggerConfig, write func(logger *FmtBasedLogger, start time.Time)) []string {
+	cfg.ShowNoTime = true
+	logger, err := NewFmtBasedLogger(cfg)
+	assert.NoError(t, err)
+	var out strings.Builder
+	logger.SetOutput(&out)
+	start := time.Date(2021, time.Month(2), 1, 3, 4, 5, 0, time.UTC)
+	logger.SetPrevTime(start)
+	write(logger, start)
+	return strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+}
+
+func Test_FmtBasedLogger_Delta_Global(t *testing.T) {
+	lines := deltaTestLines(t, LoggerConfig{}, func(logger *FmtBasedLogger, start time.Time) {
+		child := logger.WithField("id", 1).(*FmtBasedLogger)
+		logger.WriteMessage(LogLevelInfo, start.Add(time.Second), "root")
+		child.WriteMessage(LogLevelInfo, start.Add(3*time.Second), "child")
+		logger.WriteMessage(LogLevelInfo, start.Add(4*time.Second), "root")
+	})
+	assert.Equal(t, []string{
+		"[+1.000000] [INF] root",
+		"[+2.000000] [INF] child id=1",
+		"[+1.000000] [INF] root",
+	}, lines)
+}
+
+func Test_FmtBasedLogger_Delta_Logger(t *testing.T) {
+	lines := deltaTestLines(t, LoggerConfig{DeltaMode: "logger"}, func(logger *FmtBasedLogger, start time.Time) {
+		child := logger.WithField("id", 1).(*FmtBasedLogger)
+		logger.WriteMessage(LogLevelInfo, start.Add(time.Second), "root")
+		child.WriteMessage(LogLevelInfo, start.Add(3*time.Second), "child")
+		logger.WriteMessage(LogLevelInfo, start.Add(4*time.Second), "root")
+		child.WriteMessage(LogLevelInfo, start.Add(5*time.Second), "child")
+	})
+	assert.Equal(t, []string{
+		"[+1.000000] [INF] root",
+		"[+3.000000] [INF] child id=1",
+		"[+3.000000] [INF] root",
+		"[+2.000000] [INF] child id=1",
+	}, lines)
+}
+
+func Test_FmtBasedLogger_Delta_Start(t *testing.T) {
+	savedStart := processStart
+	defer func() { processStart = savedStart }()
+	lines := deltaTestLines(t, LoggerConfig{DeltaMode: "start"}, func(logger *FmtBasedLogger, start time.Time) {
+		processStart = start
+		logger.WriteMessage(LogLevelInfo, start.Add(time.Second), "first")
+		logger.WriteMessage(LogLevelInfo, start.Add(3*time.Second), "second")
+	})
+	assert.Equal(t, []string{
+		"[+1.000000] [INF] first",
+		"[+3.000000] [INF] second",
+	}, lines)
+}
+
+func Test_FmtBasedLogger_Delta_None(t *testing.T) {
+	for encoding, want := range map[string]string{
+		EncodingText:   "[INF] ready",
+		EncodingJSON:   `{"level":"info","msg":"ready"}`,
+		EncodingLogfmt: "level=info msg=ready",
+	} {
+		lines := deltaTestLines(t, LoggerConfig{DeltaMode: "none", Encoding: encoding}, func(logger *FmtBasedLogger, start time.Time) {
+			logger.WriteMessage(LogLevelInfo, start.Add(time.Second), "ready")
+		})
+		assert.Equal(t, []string{want}, lines, encoding)
+	}
+}
+
+func Test_FmtBasedLogger_Delta_Concurrent(t *testing.T) {
+	for _, mode := range []string{"global", "logger"} {
+		logger, err := NewFmtBasedLogger(LoggerConfig{DeltaMode: mode})
+		assert.NoError(t, err)
+		out := &lockedBuffer{}
+		logger.SetOutput(out)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				child := logger.WithField("worker", i)
+				for j := 0; j < 100; j++ {
+					child.Info("tick")
+					logger.Info("tock")
+				}
+			}(i)
+		}
+		wg.Wait()
+		assert.Equal(t, 8*200, strings.Count(out.String(), "\n"), mode)
+	}
+}
@@ -0,0 +1,222 @@
+package justlog
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Record is a single log line before encoding.
+type Record struct {
+	Time    time.Time
+	Delta   time.Duration
+	Level   Level
+	Name    string
+	Message []byte
+	Fields  []Field
+	Caller  string
+
+	// Flow is set for records logged within a flow, Elapsed is the time
+	// since its start then.
+	Flow    *Flow
+	Elapsed time.Duration
+
+	// fieldsBuf keeps storage for fields passed to LogFields between uses
+	// of a pooled record.
+	fieldsBuf []Field
+	// delta is the tracker of the logger writing the record in DeltaLogger
+	// mode.
+	delta *DeltaTracker
+}
+
+var recordPool = sync.Pool{
+	New: func() interface{} { return new(Record) },
+}
+
+func getRecord() *Record {
+	return recordPool.Get().(*Record)
+}
+
+// maxPooledFields keeps records with many fields from pinning memory in
+// recordPool.
+const maxPooledFields = 64
+
+func putRecord(rec *Record) {
+	fieldsBuf := rec.fieldsBuf
+	for i := range fieldsBuf {
+		fieldsBuf[i] = Field{}
+	}
+	if cap(fieldsBuf) > maxPooledFields {
+		fieldsBuf = nil
+	}
+	*rec = Record{fieldsBuf: fieldsBuf[:0]}
+	recordPool.Put(rec)
+}
+
+// setFields sets rec.Fields to base followed by fields, copied to the
+// storage of rec. A field replaces the earlier one with the same key, as in
+// mergeFields.
+func (rec *Record) setFields(base []Field, fields []Field) {
+	if len(fields) == 0 {
+		rec.Fields = base
+		return
+	}
+	rec.fieldsBuf = append(rec.fieldsBuf[:0], base...)
+NextField:
+	for _, field := range fields {
+		for i := range rec.fieldsBuf {
+			if rec.fieldsBuf[i].Key == field.Key {
+				rec.fieldsBuf[i] = field
+				continue NextField
+			}
+		}
+		rec.fieldsBuf = append(rec.fieldsBuf, field)
+	}
+	rec.Fields = rec.fieldsBuf
+}
+
+func appendMessage(buf []byte, args ...interface{}) []byte {
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i].(type) {
+		case string:
+			buf = append(buf, arg...)
+		case []byte:
+			buf = append(buf, arg...)
+		case int:
+			buf = strconv.AppendInt(buf, int64(arg), 10)
+		case int64:
+			buf = strconv.AppendInt(buf, arg, 10)
+		case uint64:
+			buf = strconv.AppendUint(buf, arg, 10)
+		case float64:
+			buf = strconv.AppendFloat(buf, arg, 'g', -1, 64)
+		case bool:
+			buf = strconv.AppendBool(buf, arg)
+		default:
+			buf = append(buf, fmt.Sprintf("%+v", arg)...)
+		}
+	}
+	return buf
+}
+
+// Encoder appends encoded rec to buf, including the trailing newline.
+type Encoder interface {
+	Encode(buf []byte, rec *Record) []byte
+}
+
+// EncoderFactory makes an encoder for cfg, deltaMode is cfg.DeltaMode
+// already parsed by the caller.
+type EncoderFactory func(cfg *LoggerConfig, deltaMode DeltaMode) (Encoder, error)
+
+var (
+	encodersMu sync.RWMutex
+	encoders   = map[string]EncoderFactory{
+		EncodingText: func(cfg *LoggerConfig, deltaMode DeltaMode) (Encoder, error) {
+			return NewTextEncoder(cfg, deltaMode), nil
+		},
+		EncodingJSON: func(cfg *LoggerConfig, deltaMode DeltaMode) (Encoder, error) {
+			return NewJSONEncoder(cfg, deltaMode), nil
+		},
+		EncodingLogfmt: func(cfg *LoggerConfig, deltaMode DeltaMode) (Encoder, error) {
+			return NewLogfmtEncoder(cfg, deltaMode), nil
+		},
+	}
+)
+
+// RegisterEncoder makes encoder available by name in LoggerConfig.Encoding.
+// Registering an existing name replaces the previous factory.
+func RegisterEncoder(name string, factory EncoderFactory) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	encoders[name] = factory
+}
+
+func NewEncoder(cfg *LoggerConfig, deltaMode DeltaMode) (Encoder, error) {
+	name := EncodingText
+	if cfg != nil && cfg.Encoding != "" {
+		name = cfg.Encoding
+	}
+
+	encodersMu.RLock()
+	factory, ok := encoders[name]
+	encodersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("invalid log encoding value: %q", name)
+	}
+	return factory(cfg, deltaMode)
+}
+
+func appendTimeFormat(buf []byte, t time.Time, format string) []byte {
+	if format == "" || format == DefaultTimeFormat {
+		return timeFormatFuncDefaultCustomized(buf, t, format)
+	}
+	return timeFormatFuncCommon(buf, t, format)
+}
+
+type TextEncoder struct {
+	TimeFormat  string
+	ShowNoTime  bool
+	ShowNoDelta bool
+	// Color colors the level tag and dims time and delta with ANSI escapes.
+	Color bool
+}
+
+func NewTextEncoder(cfg *LoggerConfig, deltaMode DeltaMode) *TextEncoder {
+	enc := &TextEncoder{TimeFormat: DefaultTimeFormat, ShowNoDelta: deltaMode == DeltaNone}
+	if cfg == nil {
+		return enc
+	}
+	if cfg.TimeFormat != "" {
+		enc.TimeFormat = cfg.TimeFormat
+	}
+	enc.ShowNoTime = cfg.ShowNoTime
+	enc.Color = cfg.Color == ColorAlways
+	return enc
+}
+
+func (enc *TextEncoder) Encode(buf []byte, rec *Record) []byte {
+	if !enc.ShowNoTime || !enc.ShowNoDelta {
+		if enc.Color {
+			buf = append(buf, ansiDim...)
+		}
+		if !enc.ShowNoTime {
+			buf = appendTimeFormat(buf, rec.Time, enc.TimeFormat)
+		}
+		if !enc.ShowNoDelta {
+			buf = append(buf, "[+"...)
+			buf = appendSeconds(buf, rec.Delta)
+			buf = append(buf, ']')
+			if rec.Flow != nil {
+				buf = append(buf, "[="...)
+				buf = appendSeconds(buf, rec.Elapsed)
+				buf = append(buf, ']')
+			}
+		}
+		if enc.Color {
+			buf = append(buf, ansiReset...)
+		}
+		buf = append(buf, ' ')
+	}
+	if enc.Color {
+		buf = append(buf, ansiLevelColor(rec.Level)...)
+		buf = append(buf, logLevelStringLocal(rec.Level)...)
+		buf = append(buf, ansiReset...)
+	} else {
+		buf = append(buf, logLevelStringLocal(rec.Level)...)
+	}
+	buf = append(buf, ' ')
+	if rec.Name != "" {
+		buf = append(buf, '[')
+		buf = append(buf, rec.Name...)
+		buf = append(buf, ']', ' ')
+	}
+	if rec.Caller != "" {
+		buf = append(buf, rec.Caller...)
+		buf = append(buf, ':', ' ')
+	}
+	buf = append(buf, rec.Message...)
+	buf = appendFieldsText(buf, rec.Fields)
+	buf = append(buf, '\n')
+	return buf
+}
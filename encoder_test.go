@@ -0,0 +1,111 @@
+package justlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type upperEncoder struct{}
+
+func (upperEncoder) Encode(buf []byte, rec *Record) []byte {
+	buf = append(buf, rec.Level.String()...)
+	buf = append(buf, ':')
+	buf = append(buf, bytes.ToUpper(rec.Message)...)
+	return append(buf, '\n')
+}
+
+func Test_RegisterEncoder_Custom(t *testing.T) {
+	RegisterEncoder("test-upper", func(cfg *LoggerConfig, deltaMode DeltaMode) (Encoder, error) {
+		return upperEncoder{}, nil
+	})
+
+	TestCase_Logger{
+		Calls: TestLoggerCalls{
+			{Method: "Warnf", Format: "log %s", Args: []interface{}{"message"}},
+		},
+		Config: &LoggerConfig{
+			Encoding: "test-upper",
+		},
+		WantOutput: "warn:LOG MESSAGE\n",
+	}.Run(t)
+}
+
+func Test_NewEncoder_Default(t *testing.T) {
+	enc, err := NewEncoder(nil, DeltaGlobal)
+	assert.NoError(t, err)
+	assert.IsType(t, &TextEncoder{}, enc)
+}
+
+func Test_FmtBasedLogger_ReportCaller(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{ShowNoTime: true, ReportCaller: true})
+	assert.NoError(t, err)
+
+	var out strings.Builder
+	logger.SetOutput(&out)
+
+	logger.WithField("k", "v").Infof("with %s", "caller")
+	assert.Regexp(t, `^\[\+\d+\.\d{6}\] \[INF\] encoder_test\.go:\d+: with caller k=v\n$`, out.String())
+}
+
+func Test_FmtBasedLogger_SetEncoder(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{})
+	assert.NoError(t, err)
+
+	var out strings.Builder
+	logger.SetOutput(&out)
+	logger.SetEncoder(upperEncoder{})
+
+	logger.WithField("ignored", 1).Error("msg")
+	assert.Equal(t, "error:MSG\n", out.String())
+}
+
+func Test_FmtBasedLogger_SetEncoder_Concurrent(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{AsyncQueueSize: 1, AsyncDropPolicy: "drop-newest", AsyncReportInterval: time.Millisecond})
+	assert.NoError(t, err)
+	logger.SetOutput(&lockedBuffer{})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			logger.WithField("i", i).Info("line")
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		logger.SetEncoder(upperEncoder{})
+		logger.SetEncoder(NewJSONEncoder(nil, DeltaGlobal))
+	}
+	<-done
+	assert.NoError(t, logger.Close())
+}
+
+func Test_LogrusFormatter_SameOutputAsFmtBasedLogger(t *testing.T) {
+	for _, encoding := range []string{EncodingText, EncodingJSON, EncodingLogfmt} {
+		cfg := LoggerConfig{Encoding: encoding}
+		prevTime := time.Date(2021, time.Month(2), 1, 3, 4, 3, 8000000, time.UTC)
+		logTime := time.Date(2021, time.Month(2), 1, 3, 4, 5, 9000000, time.UTC)
+
+		fmtLogger, err := NewFmtBasedLogger(cfg)
+		assert.NoError(t, err)
+		fmtLogger.SetPrevTime(prevTime)
+		child := fmtLogger.WithFields(Fields{"user": "joe", "n": 1}).(*FmtBasedLogger)
+		wantBytes := child.FormatMessage(nil, []byte("MSG!"), LogLevelWarn, logTime)
+
+		formatter, err := NewLogrusFormatter(&cfg)
+		assert.NoError(t, err)
+		formatter.SetPrevTime(prevTime)
+		gotBytes, err := formatter.Format(&logrus.Entry{
+			Time:    logTime,
+			Message: "MSG!",
+			Level:   logrus.WarnLevel,
+			Data:    logrus.Fields{"user": "joe", "n": 1},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, string(wantBytes), string(gotBytes), encoding)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/signal"
 	"sync"
+	"time"
 
 	"github.com/mxpaul/justlog"
 )
@@ -12,8 +13,12 @@ import (
 func main() {
 	logConfig := justlog.LoggerConfig{}
 
-	flag.StringVar(&logConfig.Level, "loglevel", "info", "print log message of this level or higher")
+	flag.StringVar(&logConfig.Level, "loglevel", "info", "print log message of this level or higher, per component: info,db=debug")
+	logConfig.LevelEnv = "LOGLEVEL"
+	flag.IntVar(&logConfig.AsyncQueueSize, "async-queue", 0, "write log asynchronously with queue of this size")
+	flag.StringVar(&logConfig.AsyncDropPolicy, "async-drop", "block", "what to do when async queue is full: block, drop-newest, drop-oldest")
 	threadCount := flag.Uint("parallel", uint(2), "how many parallel reporters to start")
+	perFlow := flag.Bool("flow", false, "count delta per goroutine and show time elapsed since it started")
 	flag.Parse()
 
 	log, err := justlog.NewLogger(logConfig)
@@ -27,6 +32,10 @@ func main() {
 	wg.Add(int(*threadCount))
 	for i := 0; i < int(*threadCount); i++ {
 		go func(num int, closer chan struct{}, wg *sync.WaitGroup) {
+			log := log
+			if *perFlow {
+				log = log.WithFlow(justlog.NewFlow(time.Now()))
+			}
 
 			for {
 				select {
@@ -52,4 +61,5 @@ func main() {
 
 	wg.Wait()
 	log.Infof("exiting")
+	log.Close()
 }
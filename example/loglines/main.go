@@ -10,7 +10,8 @@ func main() {
 
 	logConfig := justlog.LoggerConfig{}
 
-	flag.StringVar(&logConfig.Level, "loglevel", "info", "print log message of this level or higher")
+	flag.StringVar(&logConfig.Level, "loglevel", "info", "print log message of this level or higher, per component: info,db=debug")
+	logConfig.LevelEnv = "LOGLEVEL"
 	flag.Parse()
 
 	log, err := justlog.NewLogger(logConfig)
@@ -19,9 +20,11 @@ func main() {
 	}
 
 	log.Debugf("logger created")
+	op := log.StartOp("loop")
 	for i := 0; i < 10; i++ {
 		log.Infof("not exiting")
 	}
+	op.End()
 
 	log.Infof("exiting")
 }
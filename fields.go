@@ -0,0 +1,314 @@
+package justlog
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type Fields map[string]interface{}
+
+// Field is a key and a value. Fields made by typed constructors like Int or
+// Time keep the value unboxed, so they are written without allocations, and
+// their Value is nil. Err and Stringer keep the value in Value. Interface
+// returns the value of any field.
+// Field{Key: key, Value: value} is the same as Any(key, value).
+type Field struct {
+	Key   string
+	Value interface{}
+
+	kind  fieldKind
+	num   int64
+	str   string
+	bytes []byte
+	loc   *time.Location
+}
+
+type fieldKind uint8
+
+const (
+	fieldAny fieldKind = iota
+	fieldString
+	fieldInt
+	fieldUint
+	fieldFloat
+	fieldBool
+	fieldDuration
+	fieldTime
+	fieldBytes
+	fieldError
+	fieldStringer
+)
+
+func String(key string, value string) Field {
+	return Field{Key: key, kind: fieldString, str: value}
+}
+
+func Int(key string, value int) Field {
+	return Field{Key: key, kind: fieldInt, num: int64(value)}
+}
+
+func Int64(key string, value int64) Field {
+	return Field{Key: key, kind: fieldInt, num: value}
+}
+
+func Uint(key string, value uint64) Field {
+	return Field{Key: key, kind: fieldUint, num: int64(value)}
+}
+
+func Float64(key string, value float64) Field {
+	return Field{Key: key, kind: fieldFloat, num: int64(math.Float64bits(value))}
+}
+
+func Bool(key string, value bool) Field {
+	field := Field{Key: key, kind: fieldBool}
+	if value {
+		field.num = 1
+	}
+	return field
+}
+
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, kind: fieldDuration, num: int64(value)}
+}
+
+// Time keeps nanoseconds and location of value, times UnixNano can not
+// represent are boxed.
+func Time(key string, value time.Time) Field {
+	if value.Year() < 1678 || value.Year() > 2261 {
+		return Field{Key: key, Value: value}
+	}
+	return Field{Key: key, kind: fieldTime, num: value.UnixNano(), loc: value.Location()}
+}
+
+// Err is a field with key "error".
+func Err(err error) Field {
+	return Field{Key: "error", kind: fieldError, Value: err}
+}
+
+// Bytes does not copy value, it must not be changed until the line is
+// written.
+func Bytes(key string, value []byte) Field {
+	return Field{Key: key, kind: fieldBytes, bytes: value}
+}
+
+func Stringer(key string, value fmt.Stringer) Field {
+	return Field{Key: key, kind: fieldStringer, Value: value}
+}
+
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Interface returns the field value boxed, as it would be stored in Fields.
+func (field Field) Interface() interface{} {
+	switch field.kind {
+	case fieldString:
+		return field.str
+	case fieldInt:
+		return field.num
+	case fieldUint:
+		return uint64(field.num)
+	case fieldFloat:
+		return math.Float64frombits(uint64(field.num))
+	case fieldBool:
+		return field.num != 0
+	case fieldDuration:
+		return time.Duration(field.num)
+	case fieldTime:
+		return field.time()
+	case fieldBytes:
+		return field.bytes
+	}
+	return field.Value
+}
+
+func (field Field) time() time.Time {
+	t := time.Unix(0, field.num)
+	if field.loc != nil {
+		t = t.In(field.loc)
+	}
+	return t
+}
+
+// mergeFields returns a new slice with base fields followed by add fields in
+// key order. Keys already present in base are overwritten in place.
+func mergeFields(base []Field, add Fields) []Field {
+	keys := make([]string, 0, len(add))
+	for key := range add {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	merged := make([]Field, len(base), len(base)+len(keys))
+	copy(merged, base)
+NextKey:
+	for _, key := range keys {
+		for i := range merged {
+			if merged[i].Key == key {
+				merged[i] = Field{Key: key, Value: add[key]}
+				continue NextKey
+			}
+		}
+		merged = append(merged, Field{Key: key, Value: add[key]})
+	}
+	return merged
+}
+
+func appendFieldValue(buf []byte, value interface{}) []byte {
+	switch v := value.(type) {
+	case string:
+		return append(buf, v...)
+	case []byte:
+		return append(buf, v...)
+	case error:
+		return append(buf, v.Error()...)
+	}
+	return append(buf, fmt.Sprintf("%+v", value)...)
+}
+
+// appendField appends value of typed fields directly, others go through
+// appendFieldValue.
+func appendField(buf []byte, field *Field) []byte {
+	switch field.kind {
+	case fieldString:
+		return append(buf, field.str...)
+	case fieldInt:
+		return strconv.AppendInt(buf, field.num, 10)
+	case fieldUint:
+		return strconv.AppendUint(buf, uint64(field.num), 10)
+	case fieldFloat:
+		return strconv.AppendFloat(buf, math.Float64frombits(uint64(field.num)), 'g', -1, 64)
+	case fieldBool:
+		return strconv.AppendBool(buf, field.num != 0)
+	case fieldDuration:
+		return appendDuration(buf, time.Duration(field.num))
+	case fieldTime:
+		return field.time().AppendFormat(buf, time.RFC3339Nano)
+	case fieldBytes:
+		return append(buf, field.bytes...)
+	case fieldError:
+		if field.Value == nil {
+			return append(buf, "<nil>"...)
+		}
+	case fieldStringer:
+		if field.Value == nil {
+			return append(buf, "<nil>"...)
+		}
+		return append(buf, field.Value.(fmt.Stringer).String()...)
+	}
+	return appendFieldValue(buf, field.Value)
+}
+
+// appendFieldsText writes fields as logfmt does, values with spaces, quotes
+// or control characters are quoted so they can not forge another line.
+func appendFieldsText(buf []byte, fields []Field) []byte {
+	return appendFieldsLogfmt(buf, fields)
+}
+
+// appendDuration appends d as time.Duration.String does.
+func appendDuration(buf []byte, d time.Duration) []byte {
+	// Largest value is "-2562047h47m16.854775808s"
+	var b [32]byte
+	w := len(b)
+
+	u := uint64(d)
+	neg := d < 0
+	if neg {
+		u = -u
+	}
+
+	if u < uint64(time.Second) {
+		// Special case: if duration is smaller than a second,
+		// use smaller units, like 1.2ms
+		var prec int
+		w--
+		b[w] = 's'
+		w--
+		switch {
+		case u == 0:
+			return append(buf, "0s"...)
+		case u < uint64(time.Microsecond):
+			prec = 0
+			b[w] = 'n'
+		case u < uint64(time.Millisecond):
+			prec = 3
+			// U+00B5 'µ' micro sign == 0xC2 0xB5
+			w--
+			copy(b[w:], "µ")
+		default:
+			prec = 6
+			b[w] = 'm'
+		}
+		w, u = fmtFrac(b[:w], u, prec)
+		w = fmtInt(b[:w], u)
+	} else {
+		w--
+		b[w] = 's'
+
+		w, u = fmtFrac(b[:w], u, 9)
+
+		// u is now integer seconds
+		w = fmtInt(b[:w], u%60)
+		u /= 60
+
+		// u is now integer minutes
+		if u > 0 {
+			w--
+			b[w] = 'm'
+			w = fmtInt(b[:w], u%60)
+			u /= 60
+
+			// u is now integer hours
+			if u > 0 {
+				w--
+				b[w] = 'h'
+				w = fmtInt(b[:w], u)
+			}
+		}
+	}
+
+	if neg {
+		w--
+		b[w] = '-'
+	}
+	return append(buf, b[w:]...)
+}
+
+// fmtFrac and fmtInt are stolen from time package.
+func fmtFrac(buf []byte, v uint64, prec int) (nw int, nv uint64) {
+	// Omit trailing zeros up to and including decimal point.
+	w := len(buf)
+	print := false
+	for i := 0; i < prec; i++ {
+		digit := v % 10
+		print = print || digit != 0
+		if print {
+			w--
+			buf[w] = byte(digit) + '0'
+		}
+		v /= 10
+	}
+	if print {
+		w--
+		buf[w] = '.'
+	}
+	return w, v
+}
+
+func fmtInt(buf []byte, v uint64) int {
+	w := len(buf)
+	if v == 0 {
+		w--
+		buf[w] = '0'
+	} else {
+		for v > 0 {
+			w--
+			buf[w] = byte(v%10) + '0'
+			v /= 10
+		}
+	}
+	return w
+}
@@ -0,0 +1,139 @@
+package justlog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testTypedFields() []Field {
+	return []Field{
+		String("method", "GET"),
+		Int("status", 200),
+		Int64("size", -1),
+		Uint("id", math.MaxUint64),
+		Float64("ratio", 0.25),
+		Bool("cached", true),
+		Duration("took", 1500*time.Microsecond),
+		Time("started", time.Date(2021, time.Month(2), 1, 3, 4, 5, 9000000, time.UTC)),
+		Bytes("body", []byte(`say "hi"`)),
+		Err(errors.New("failed")),
+		Stringer("ip", net.IPv4(10, 0, 0, 1)),
+		Any("list", []int{1, 2}),
+	}
+}
+
+func Test_appendFieldsText_Typed(t *testing.T) {
+	assert.Equal(t, ` method=GET status=200 size=-1 id=18446744073709551615 ratio=0.25 cached=true`+
+		` took=1.5ms started=2021-02-01T03:04:05.009Z body="say \"hi\"" error=failed ip=10.0.0.1 list="[1 2]"`,
+		string(appendFieldsText(nil, testTypedFields())))
+}
+
+func Test_FmtBasedLogger_WithField_ForgedLine(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{ShowNoTime: true, DeltaMode: "none"})
+	assert.NoError(t, err)
+	var out strings.Builder
+	logger.SetOutput(&out)
+	logger.WithFields(Fields{"user": "joe\n2021 [ERR] fake", "empty": ""}).Info("login")
+	assert.Equal(t, `[INF] login empty="" user="joe\n2021 [ERR] fake"`+"\n", out.String())
+}
+
+func Test_appendFieldsJSON_Typed(t *testing.T) {
+	assert.Equal(t, `,"method":"GET","status":200,"size":-1,"id":18446744073709551615,"ratio":0.25,"cached":true,`+
+		`"took":"1.5ms","started":"2021-02-01T03:04:05.009Z","body":"say \"hi\"","error":"failed","ip":"10.0.0.1","list":"[1 2]"`,
+		string(appendFieldsJSON(nil, testTypedFields())))
+}
+
+func Test_appendFieldsLogfmt_Typed(t *testing.T) {
+	assert.Equal(t, ` method=GET status=200 size=-1 id=18446744073709551615 ratio=0.25 cached=true`+
+		` took=1.5ms started=2021-02-01T03:04:05.009Z body="say \"hi\"" error=failed ip=10.0.0.1 list="[1 2]"`,
+		string(appendFieldsLogfmt(nil, testTypedFields())))
+}
+
+func Test_Field_Interface(t *testing.T) {
+	started := time.Date(2021, time.Month(2), 1, 3, 4, 5, 0, time.FixedZone("MSK", 3*3600))
+	assert.Equal(t, int64(200), Int("status", 200).Interface())
+	assert.Equal(t, 0.25, Float64("ratio", 0.25).Interface())
+	assert.Equal(t, false, Bool("cached", false).Interface())
+	assert.Equal(t, time.Second, Duration("took", time.Second).Interface())
+	assert.Equal(t, started.String(), Time("started", started).Interface().(time.Time).String())
+	assert.Equal(t, "v", Any("k", "v").Interface())
+	assert.Nil(t, Time("started", started).Value)
+	assert.Nil(t, Int("status", 200).Value)
+}
+
+func Test_appendDuration(t *testing.T) {
+	for _, d := range []time.Duration{
+		0, 1, 999, time.Microsecond, 1500 * time.Microsecond, time.Second, -90 * time.Minute,
+		26*time.Hour + 3*time.Second + 7, math.MaxInt64, math.MinInt64,
+	} {
+		assert.Equal(t, d.String(), string(appendDuration(nil, d)))
+	}
+}
+
+func Test_appendMessage_Scalars(t *testing.T) {
+	for _, arg := range []interface{}{5, int64(-6), uint64(7), 1e21, 0.5, 1e-7, true, time.Second} {
+		assert.Equal(t, fmt.Sprintf("%+v", arg), string(appendMessage(nil, arg)))
+	}
+}
+
+func Test_FmtBasedLogger_LogFields(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{ShowNoTime: true})
+	assert.NoError(t, err)
+	var out strings.Builder
+	logger.SetOutput(&out)
+
+	child := logger.WithField("user", "joe")
+	child.LogFields(LogLevelDebug, "filtered", Int("status", 200))
+	child.LogFields(LogLevelWarn, "done", Int("status", 200), Duration("took", 1500*time.Microsecond))
+	child.Info("fields of the call are not kept")
+
+	assert.Equal(t, " [WRN] done user=joe status=200 took=1.5ms\n [INF] fields of the call are not kept user=joe\n",
+		stripDelta(out.String()))
+}
+
+func Test_FmtBasedLogger_LogFields_DuplicateKey(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{ShowNoTime: true, Encoding: EncodingJSON, DeltaMode: "none"})
+	assert.NoError(t, err)
+	var out strings.Builder
+	logger.SetOutput(&out)
+
+	child := logger.WithFields(Fields{"user": "a", "n": 1})
+	child.LogFields(LogLevelInfo, "replaced", String("user", "b"), Int("status", 200), Int("status", 201))
+	child.Info("kept")
+
+	assert.Equal(t, `{"level":"info","msg":"replaced","n":1,"user":"b","status":201}`+"\n"+
+		`{"level":"info","msg":"kept","n":1,"user":"a"}`+"\n", out.String())
+}
+
+func Test_HandlerBasedLogger_LogFields_DuplicateKey(t *testing.T) {
+	h := &testHandler{Level: LogLevelInfo}
+	logger := NewHandlerBasedLogger(h)
+	logger.WithField("user", "a").LogFields(LogLevelInfo, "replaced", String("user", "b"))
+
+	if assert.Len(t, h.Records, 1) && assert.Len(t, h.Records[0].Fields, 1) {
+		assert.Equal(t, "b", h.Records[0].Fields[0].Interface())
+	}
+}
+
+func Test_FmtBasedLogger_LogFields_NoAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool is not reliable under race detector")
+	}
+	logger, err := NewFmtBasedLogger(LoggerConfig{})
+	assert.NoError(t, err)
+	logger.SetOutput(io.Discard)
+	started := time.Date(2021, time.Month(2), 1, 3, 4, 5, 0, time.UTC)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		logger.LogFields(LogLevelInfo, "done", Int("status", 200), Duration("took", time.Millisecond), Time("started", started))
+	})
+	assert.Equal(t, 0.0, allocs)
+}
@@ -0,0 +1,87 @@
+package justlog
+
+import (
+	"context"
+	"time"
+)
+
+// Flow is a unit of work like a request, possibly logged by several loggers
+// and goroutines. Lines of a flow show delta since the previous line of the
+// same flow whatever DeltaMode is, and time elapsed since Start.
+type Flow struct {
+	Start time.Time
+	delta DeltaTracker
+}
+
+func NewFlow(start time.Time) *Flow {
+	flow := &Flow{Start: start}
+	flow.delta.Set(start)
+	return flow
+}
+
+type flowContextKey struct{}
+
+func ContextWithFlow(ctx context.Context, flow *Flow) context.Context {
+	return context.WithValue(ctx, flowContextKey{}, flow)
+}
+
+// NewFlowContext returns ctx with a new flow started now.
+func NewFlowContext(ctx context.Context) context.Context {
+	return ContextWithFlow(ctx, NewFlow(time.Now()))
+}
+
+// FlowFromContext returns flow attached to ctx or nil.
+func FlowFromContext(ctx context.Context) *Flow {
+	if ctx == nil {
+		return nil
+	}
+	flow, _ := ctx.Value(flowContextKey{}).(*Flow)
+	return flow
+}
+
+// setRecordDelta sets Delta and Elapsed of rec, tracker is used for records
+// out of any flow.
+func setRecordDelta(rec *Record, mode DeltaMode, tracker *DeltaTracker) {
+	if rec.Flow == nil || mode == DeltaNone {
+		rec.Delta = deltaFor(mode, tracker, rec.Time)
+		return
+	}
+	rec.Delta = rec.Flow.delta.Swap(rec.Time)
+	rec.Elapsed = rec.Time.Sub(rec.Flow.Start)
+}
+
+// WithFlow returns child logger writing lines of flow, nil flow detaches the
+// child from the flow of logger.
+func (logger *FmtBasedLogger) WithFlow(flow *Flow) *FmtBasedLogger {
+	child := logger.child()
+	child.flow = flow
+	return child
+}
+
+// WithContext returns child logger writing lines of the flow attached to
+// ctx, or logger itself if there is none.
+func (logger *FmtBasedLogger) WithContext(ctx context.Context) *FmtBasedLogger {
+	flow := FlowFromContext(ctx)
+	if flow == nil {
+		return logger
+	}
+	return logger.WithFlow(flow)
+}
+
+func (logger *HandlerBasedLogger) WithFlow(flow *Flow) *HandlerBasedLogger {
+	return &HandlerBasedLogger{
+		Handler:      logger.Handler,
+		ReportCaller: logger.ReportCaller,
+		ErrorFunc:    logger.ErrorFunc,
+		fields:       logger.fields,
+		flow:         flow,
+	}
+}
+
+func (logger *HandlerBasedLogger) WithContext(ctx context.Context) *HandlerBasedLogger {
+	flow := FlowFromContext(ctx)
+	if flow == nil {
+		return logger
+	}
+	return logger.WithFlow(flow)
+}
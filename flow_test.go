@@ -0,0 +1,98 @@
+package justlog
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FmtBasedLogger_WithFlow_Interleaved(t *testing.T) {
+	lines := deltaTestLines(t, LoggerConfig{}, func(logger *FmtBasedLogger, start time.Time) {
+		first := logger.WithFlow(NewFlow(start)).WithField("req", 1)
+		second := logger.WithFlow(NewFlow(start.Add(time.Second))).WithField("req", 2)
+		first.(*FmtBasedLogger).WriteMessage(LogLevelInfo, start.Add(time.Second), "started")
+		second.(*FmtBasedLogger).WriteMessage(LogLevelInfo, start.Add(2*time.Second), "started")
+		logger.WriteMessage(LogLevelInfo, start.Add(3*time.Second), "global")
+		first.(*FmtBasedLogger).WriteMessage(LogLevelInfo, start.Add(4*time.Second), "done")
+		second.(*FmtBasedLogger).WriteMessage(LogLevelInfo, start.Add(6*time.Second), "done")
+	})
+	assert.Equal(t, []string{
+		"[+1.000000][=1.000000] [INF] started req=1",
+		"[+1.000000][=1.000000] [INF] started req=2",
+		"[+3.000000] [INF] global",
+		"[+3.000000][=4.000000] [INF] done req=1",
+		"[+4.000000][=5.000000] [INF] done req=2",
+	}, lines)
+}
+
+func Test_FmtBasedLogger_WithContext(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{})
+	assert.NoError(t, err)
+	assert.True(t, logger == logger.WithContext(context.Background()))
+
+	ctx := NewFlowContext(context.Background())
+	flow := FlowFromContext(ctx)
+	assert.NotNil(t, flow)
+	child := logger.WithContext(ctx)
+	assert.True(t, flow == child.flow)
+	assert.True(t, flow == child.Named("db").WithField("id", 1).(*FmtBasedLogger).flow)
+	assert.Nil(t, child.WithFlow(nil).flow)
+	assert.Nil(t, FlowFromContext(nil))
+}
+
+func Test_FmtBasedLogger_WithFlow_Encodings(t *testing.T) {
+	for encoding, want := range map[string]string{
+		EncodingJSON:   `{"delta":2.000000,"elapsed":3.000000,"level":"info","msg":"ready"}`,
+		EncodingLogfmt: "delta=2.000000 elapsed=3.000000 level=info msg=ready",
+	} {
+		lines := deltaTestLines(t, LoggerConfig{Encoding: encoding}, func(logger *FmtBasedLogger, start time.Time) {
+			flow := NewFlow(start.Add(-time.Second))
+			flow.delta.Set(start)
+			logger.WithFlow(flow).WriteMessage(LogLevelInfo, start.Add(2*time.Second), "ready")
+		})
+		assert.Equal(t, []string{want}, lines, encoding)
+	}
+
+	lines := deltaTestLines(t, LoggerConfig{DeltaMode: "none"}, func(logger *FmtBasedLogger, start time.Time) {
+		logger.WithFlow(NewFlow(start)).WriteMessage(LogLevelInfo, start.Add(time.Second), "ready")
+	})
+	assert.Equal(t, []string{"[INF] ready"}, lines)
+}
+
+func Test_HandlerBasedLogger_WithContext(t *testing.T) {
+	var out strings.Builder
+	tee, err := NewTeeHandler(Sink{Out: &out, Encoder: &TextEncoder{ShowNoTime: true}})
+	assert.NoError(t, err)
+	logger := NewHandlerBasedLogger(tee)
+	assert.True(t, logger == logger.WithContext(context.Background()))
+
+	ctx := ContextWithFlow(context.Background(), NewFlow(time.Now()))
+	logger.WithContext(ctx).WithField("req", 1).Info("started")
+	assert.True(t, strings.HasSuffix(out.String(), "] [INF] started req=1\n"), out.String())
+	assert.Contains(t, out.String(), "][=")
+}
+
+func Test_FmtBasedLogger_WithFlow_Concurrent(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{})
+	assert.NoError(t, err)
+	out := &lockedBuffer{}
+	logger.SetOutput(out)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			flow := NewFlow(time.Now())
+			for j := 0; j < 100; j++ {
+				logger.WithFlow(flow).WithField("req", i).Info("step")
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 800, strings.Count(out.String(), "][="))
+}
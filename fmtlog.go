@@ -1,10 +1,17 @@
 package justlog
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 )
 
@@ -51,102 +58,530 @@ func timeFormatFuncDefaultCustomized(buf []byte, t time.Time, _ string) []byte {
 	return buf
 }
 
+// appendSeconds prints duration as seconds with microsecond precision,
+// same as fmt "%.6f" of d.Seconds() would do.
+func appendSeconds(buf []byte, d time.Duration) []byte {
+	if d < 0 {
+		buf = append(buf, '-')
+		d = -d
+	}
+	us := int64((d + time.Microsecond/2) / time.Microsecond)
+	buf = itoa(buf, int(us/1e6), 1)
+	buf = append(buf, '.')
+	return itoa(buf, int(us%1e6), 6)
+}
+
+func callerString(depth int) string {
+	_, file, line, ok := runtime.Caller(depth + 1)
+	if !ok {
+		return "???:0"
+	}
+	return filepath.Base(file) + ":" + strconv.Itoa(line)
+}
+
+// maxPooledMessage keeps occasional huge messages from pinning memory in
+// messageBufferPool.
+const maxPooledMessage = 64 << 10
+
+var messageBufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+func getMessageBuffer() *bytes.Buffer {
+	msg := messageBufferPool.Get().(*bytes.Buffer)
+	msg.Reset()
+	return msg
+}
+
+func putMessageBuffer(msg *bytes.Buffer) {
+	if msg.Cap() <= maxPooledMessage {
+		messageBufferPool.Put(msg)
+	}
+}
+
+// lineBufferPool holds buffers for encoded lines, outputs must not keep
+// the written slice as io.Writer requires.
+var lineBufferPool = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, 0, 256)
+		return &buf
+	},
+}
+
+// formatOverhead is enough room for the time, delta and level of a JSON line,
+// so FormatMessage does not grow the buffer for short messages.
+const formatOverhead = 96
+
 func NewFmtBasedLogger(cfg LoggerConfig) (*FmtBasedLogger, error) {
-	logLevel, err := ParseLogLevel(cfg.Level)
+	levelSpec, err := levelSpecFromConfig(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("ParseLevelSpec error: %w", err)
+	}
+
+	if err := checkColorMode(cfg.Color); err != nil {
+		return nil, err
+	}
+
+	deltaMode, err := ParseDeltaMode(cfg.DeltaMode)
+	if err != nil {
+		return nil, fmt.Errorf("ParseDeltaMode error: %w", err)
+	}
+
+	encoder, err := NewEncoder(&cfg, deltaMode)
+	if err != nil {
+		return nil, fmt.Errorf("NewEncoder error: %w", err)
+	}
+
+	dropPolicy, err := ParseDropPolicy(cfg.AsyncDropPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("ParseDropPolicy error: %w", err)
+	}
+
+	sampler, err := NewSamplerFromConfig(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("NewSamplerFromConfig error: %w", err)
+	}
+
+	opLevel, err := ParseLogLevel(cfg.OpLevel)
 	if err != nil {
-		return nil, fmt.Errorf("ParseLevel error: %w", err)
+		return nil, fmt.Errorf("OpLevel error: %w", err)
 	}
 
 	logger := &FmtBasedLogger{
-		TimeFormat:     DefaultTimeFormat,
-		PrevTime:       time.Now(),
-		ShowNoTime:     cfg.ShowNoTime,
-		Level:          logLevel,
-		Out:            os.Stderr,
-		timeFormatFunc: timeFormatFuncCommon,
+		deltaMode:    deltaMode,
+		delta:        NewDeltaTracker(time.Now()),
+		ReportCaller: cfg.ReportCaller,
+		Out:          os.Stderr,
+		node:         &levelNode{},
+		sampler:      sampler,
+		opLevel:      opLevel,
+		opThreshold:  cfg.OpThreshold,
+	}
+	logger.encoder.Store(&encoder)
+	logger.ApplyLevelSpec(levelSpec)
+	if cfg.Dedup {
+		logger.dedup = newDedupWriter(cfg.DedupTimeout)
+	}
+
+	if cfg.File != "" {
+		file, err := NewRotatingFileWriter(&cfg)
+		if err != nil {
+			return nil, fmt.Errorf("NewRotatingFileWriter error: %w", err)
+		}
+		file.ErrorFunc = func(err error) {
+			logger.Errorf("log file maintenance: %v", err)
+		}
+		logger.Out = file
+	}
+
+	if cfg.Color == ColorAuto {
+		logger.colorAuto = true
+		logger.updateColor(logger.Out)
 	}
 
-	if cfg.TimeFormat != "" {
-		logger.TimeFormat = cfg.TimeFormat
+	if cfg.File != "" && cfg.FileReopenOnSIGHUP {
+		logger.stopReopen = logger.ReopenOnSignal(syscall.SIGHUP)
 	}
 
-	if logger.TimeFormat == DefaultTimeFormat {
-		logger.timeFormatFunc = timeFormatFuncDefaultCustomized
+	if cfg.AsyncQueueSize > 0 {
+		async := NewAsyncWriter(logger.Out, cfg.AsyncQueueSize, dropPolicy, cfg.AsyncReportInterval)
+		async.DropReport = logger.dropReport
+		async.ErrorFunc = logger.reportError
+		logger.Out = async
+		logger.async = async
 	}
 
 	return logger, nil
 }
 
 type FmtBasedLogger struct {
-	PrevTime       time.Time
-	TimeFormat     string
-	ShowNoTime     bool
-	Level          Level
-	Out            io.Writer
-	outMu          sync.Mutex
-	timeFormatFunc func([]byte, time.Time, string) []byte
+	ReportCaller bool
+	Out          io.Writer
+	outMu        sync.Mutex
+	async        *AsyncWriter
+	stopReopen   func()
+
+	// ErrorFunc receives errors of hooks and of the output, rotation
+	// failures among them. They are written to stderr when it is nil.
+	ErrorFunc func(err error)
+	hooks     hookSet
+
+	// parent is set for child loggers created by WithField, WithFields and
+	// Named. Children keep no settings of their own and write through the
+	// root.
+	parent *FmtBasedLogger
+	fields []Field
+
+	// name and node are set by Named, node is shared with the parent
+	// otherwise. See named.go.
+	name    string
+	node    *levelNode
+	namedMu sync.Mutex
+	named   map[string]*levelNode
+
+	// sampler is inherited by children, see WithSampler.
+	sampler *Sampler
+	dedup   *dedupWriter
+
+	// colorAuto makes SetOutput turn color of TextEncoder on or off. The
+	// encoder is not changed, lines are encoded by its copy with Color set
+	// to colorOn.
+	colorAuto bool
+	colorOn   uint32 // accessed atomically
+
+	// encoder holds *Encoder, SetEncoder replaces it while other goroutines
+	// are logging.
+	encoder atomic.Value
+
+	// delta is shared with children unless deltaMode is DeltaLogger.
+	deltaMode DeltaMode
+	delta     *DeltaTracker
+	flow      *Flow
+
+	// op is set for loggers returned from Op.Logger.
+	op          *Op
+	opLevel     Level
+	opThreshold time.Duration
+}
+
+func (logger *FmtBasedLogger) root() *FmtBasedLogger {
+	for logger.parent != nil {
+		logger = logger.parent
+	}
+	return logger
+}
+
+// fmtCallerDepth is the number of frames between runtime.Caller in
+// newRecord or sample and the code calling a logging method, when they are
+// called from WriteMessage or WriteMessagef.
+const fmtCallerDepth = 3
+
+// fillRecord sets the record fields every line of logger has. Message and
+// Fields are left to the caller.
+func (logger *FmtBasedLogger) fillRecord(rec *Record, level Level, t time.Time) {
+	rec.Time = t
+	rec.Level = level
+	rec.Name = logger.name
+	rec.delta = logger.delta
+	rec.Flow = logger.flow
+}
+
+// newRecord returns a pooled record filled by fillRecord, with Caller set
+// when ReportCaller is on. Caller is depth frames up from newRecord. The
+// record is released by putRecord.
+func (logger *FmtBasedLogger) newRecord(level Level, t time.Time, depth int) *Record {
+	rec := getRecord()
+	logger.fillRecord(rec, level, t)
+	if logger.root().ReportCaller {
+		rec.Caller = callerString(depth)
+	}
+	return rec
+}
+
+// logRecord fires hooks and writes rec unless a hook vetoes it.
+func (logger *FmtBasedLogger) logRecord(rec *Record) error {
+	if !logger.fireHooks(rec) {
+		return nil
+	}
+	return logger.writeRecord(rec)
+}
+
+// logLine is logRecord for logging methods, which have no error result.
+func (logger *FmtBasedLogger) logLine(rec *Record) {
+	if err := logger.logRecord(rec); err != nil {
+		logger.root().reportError(err)
+	}
 }
 
 func (logger *FmtBasedLogger) WriteMessage(Level Level, Time time.Time, args ...interface{}) {
-	if logger.Level > Level {
+	if logger.GetLevel() > Level || !logger.sample(Level, "", Time, fmtCallerDepth) {
 		return
 	}
-	msg := logger.MessageBytes(nil, args...)
-	buf := make([]byte, 0, len(msg)+45)
-	buf = logger.FormatMessage(buf, msg, Level, Time)
-	logger.outMu.Lock()
-	defer logger.outMu.Unlock()
-	logger.Out.Write(buf)
+	rec := logger.newRecord(Level, Time, fmtCallerDepth)
+	defer putRecord(rec)
+	rec.Message = logger.MessageBytes(nil, args...)
+	rec.Fields = logger.fields
+	logger.logLine(rec)
 }
 
 func (logger *FmtBasedLogger) WriteMessagef(Level Level, Time time.Time, format string, args ...interface{}) {
-	if logger.Level > Level {
+	if logger.GetLevel() > Level || !logger.sample(Level, format, Time, fmtCallerDepth) {
+		return
+	}
+	rec := logger.newRecord(Level, Time, fmtCallerDepth)
+	defer putRecord(rec)
+	msg := getMessageBuffer()
+	defer putMessageBuffer(msg)
+	fmt.Fprintf(msg, format, args...)
+	rec.Message = msg.Bytes()
+	rec.Fields = logger.fields
+	logger.logLine(rec)
+}
+
+// LogFields writes fields made by typed constructors without allocations.
+func (logger *FmtBasedLogger) LogFields(level Level, msg string, fields ...Field) {
+	logger.WriteFields(level, time.Now(), msg, fields)
+}
+
+func (logger *FmtBasedLogger) WriteFields(Level Level, Time time.Time, msg string, fields []Field) {
+	logger.writeFields(Level, Time, msg, fields, fmtCallerDepth+1)
+}
+
+// writeFields is WriteFields reporting the caller depth frames up from it,
+// for wrappers adding frames, like Op.End.
+func (logger *FmtBasedLogger) writeFields(Level Level, Time time.Time, msg string, fields []Field, depth int) {
+	if logger.GetLevel() > Level || !logger.sample(Level, msg, Time, depth) {
 		return
 	}
-	msg := []byte(fmt.Sprintf(format, args...))
-	buf := make([]byte, 0, len(msg)+45)
-	buf = logger.FormatMessage(buf, msg, Level, Time)
+	rec := logger.newRecord(Level, Time, depth)
+	defer putRecord(rec)
+	buf := getMessageBuffer()
+	defer putMessageBuffer(buf)
+	buf.WriteString(msg)
+	rec.Message = buf.Bytes()
+	rec.setFields(logger.fields, fields)
+	logger.logLine(rec)
+}
+
+func (logger *FmtBasedLogger) writeRecord(rec *Record) error {
+	root := logger.root()
+	if root.dedup != nil {
+		return root.dedup.write(root, rec)
+	}
+	return root.writeEncoded(rec)
+}
+
+// writeEncoded must be called on the root logger.
+func (logger *FmtBasedLogger) writeEncoded(rec *Record) error {
+	bufp := lineBufferPool.Get().(*[]byte)
+	buf := logger.encodeRecord((*bufp)[:0], rec)
 
 	logger.outMu.Lock()
-	defer logger.outMu.Unlock()
-	logger.Out.Write(buf)
+	_, err := logger.Out.Write(buf)
+	logger.outMu.Unlock()
+
+	if cap(buf) <= maxPooledMessage {
+		*bufp = buf
+		lineBufferPool.Put(bufp)
+	}
+	return err
 }
 
-func (logger *FmtBasedLogger) FormatMessage(buf []byte, Message []byte, Level Level, Time time.Time) []byte {
-	sinceLastLog := Time.Sub(logger.PrevTime) // FIXME: store time with atomic
-	logger.PrevTime = Time
+func (logger *FmtBasedLogger) Enabled(level Level) bool {
+	return logger.GetLevel() <= level
+}
 
-	if !logger.ShowNoTime {
-		buf = logger.timeFormatFunc(buf, Time, logger.TimeFormat)
+// SetLevel changes minimal level of lines written. It is safe to call while
+// other goroutines are logging. Children created by WithField share the level
+// of their parent, named children have their own, see Named.
+func (logger *FmtBasedLogger) SetLevel(level Level) {
+	atomic.StoreUint32(&logger.node.level, uint32(level))
+}
+
+// GetLevel returns effective level, which for named loggers without own
+// level is inherited from the parent.
+func (logger *FmtBasedLogger) GetLevel() Level {
+	return logger.node.effective()
+}
+
+// Handle fires hooks and writes rec, fields of logger are not added. This
+// makes FmtBasedLogger usable as a Handler for HandlerBasedLogger.
+func (logger *FmtBasedLogger) Handle(rec *Record) error {
+	return logger.logRecord(rec)
+}
+
+func (logger *FmtBasedLogger) encodeRecord(buf []byte, rec *Record) []byte {
+	delta := rec.delta
+	if delta == nil {
+		delta = logger.delta
+	}
+	setRecordDelta(rec, logger.deltaMode, delta)
+	encoder := logger.getEncoder()
+	if logger.colorAuto {
+		if enc, ok := encoder.(*TextEncoder); ok {
+			colored := *enc
+			colored.Color = atomic.LoadUint32(&logger.colorOn) == 1
+			return colored.Encode(buf, rec)
+		}
 	}
+	return encoder.Encode(buf, rec)
+}
 
-	buf = append(buf, "[+"...)
-	buf = append(buf, fmt.Sprintf("%.6f", sinceLastLog.Seconds())...) // FIXME: split for seconds and nanoseconds, use itoa
-	buf = append(buf, ']')
-	buf = append(buf, ' ')
-	buf = append(buf, logLevelStringLocal(Level)...)
-	buf = append(buf, ' ')
-	buf = append(buf, Message...)
-	buf = append(buf, '\n')
-	return buf
+func (logger *FmtBasedLogger) FormatMessage(buf []byte, Message []byte, Level Level, Time time.Time) []byte {
+	rec := Record{Message: Message, Fields: logger.fields}
+	logger.fillRecord(&rec, Level, Time)
+	return logger.root().encodeRecord(buf, &rec)
+}
+
+// SetPrevTime sets time the delta of the next line is counted from.
+func (logger *FmtBasedLogger) SetPrevTime(prev time.Time) {
+	logger.delta.Set(prev)
+}
+
+// SetEncoder replaces the encoder of lines. It is safe to call while other
+// goroutines are logging.
+func (logger *FmtBasedLogger) SetEncoder(encoder Encoder) {
+	logger.root().encoder.Store(&encoder)
+}
+
+// getEncoder must be called on the root logger.
+func (logger *FmtBasedLogger) getEncoder() Encoder {
+	return *logger.encoder.Load().(*Encoder)
 }
 
 func (logger *FmtBasedLogger) MessageBytes(buf []byte, args ...interface{}) []byte {
-	for i := 0; i < len(args); i++ {
-		switch arg := args[i].(type) {
-		case string:
-			buf = append(buf, arg...)
-		case []byte:
-			buf = append(buf, arg...)
-		default:
-			buf = append(buf, fmt.Sprintf("%+v", arg)...)
+	return appendMessage(buf, args...)
+}
+
+// SetOutput replaces output writer. With async mode on, the writer behind
+// the queue is replaced instead.
+func (logger *FmtBasedLogger) SetOutput(out io.Writer) {
+	root := logger.root()
+	root.outMu.Lock()
+	defer root.outMu.Unlock()
+	if root.colorAuto {
+		root.updateColor(out)
+	}
+	if root.async != nil {
+		root.async.SetOutput(out)
+		return
+	}
+	root.Out = out
+}
+
+func (logger *FmtBasedLogger) updateColor(out io.Writer) {
+	var colorOn uint32
+	if colorEnabled(out) {
+		colorOn = 1
+	}
+	atomic.StoreUint32(&logger.colorOn, colorOn)
+}
+
+// Reopen makes output reopen its file if it supports that, see Reopener.
+// Lines being written at the moment of the call go to the old file.
+func (logger *FmtBasedLogger) Reopen() error {
+	root := logger.root()
+	root.outMu.Lock()
+	defer root.outMu.Unlock()
+	if reopener, ok := root.Out.(Reopener); ok {
+		return reopener.Reopen()
+	}
+	return nil
+}
+
+// ReopenOnSignal calls Reopen every time one of signals is received, SIGHUP
+// by default. Reopen errors are logged. Call stop to uninstall the handler.
+func (logger *FmtBasedLogger) ReopenOnSignal(signals ...os.Signal) (stop func()) {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGHUP}
+	}
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, signals...)
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-sigCh:
+				if err := logger.Reopen(); err != nil {
+					logger.Errorf("reopen log output: %v", err)
+				}
+			case <-done:
+				return
+			}
 		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(sigCh)
+			close(done)
+		})
 	}
-	return buf
 }
 
-func (logger *FmtBasedLogger) SetOutput(out io.Writer) {
-	logger.Out = out
+// Flush writes pending repeat count in dedup mode and waits for queued lines
+// to be written when async mode is on.
+func (logger *FmtBasedLogger) Flush() error {
+	root := logger.root()
+	if root.dedup != nil {
+		root.dedup.flush(root)
+	}
+	if root.async != nil {
+		return root.async.Flush()
+	}
+	return nil
+}
+
+// Close flushes queued lines and closes the output, unless it is os.Stdout
+// or os.Stderr. The logger must not be used after Close.
+func (logger *FmtBasedLogger) Close() error {
+	root := logger.root()
+	if root.stopReopen != nil {
+		root.stopReopen()
+	}
+	if root.dedup != nil {
+		root.dedup.flush(root)
+	}
+	root.outMu.Lock()
+	out, async := root.Out, root.async
+	root.Out, root.async = io.Discard, nil
+	root.outMu.Unlock()
+
+	// outputs may log their own errors while closing, so outMu is released
+	if async != nil {
+		return async.Close()
+	}
+	return closeOutput(out)
+}
+
+func (logger *FmtBasedLogger) dropReport(dropped uint64) []byte {
+	rec := Record{
+		Time:    time.Now(),
+		Level:   LogLevelWarn,
+		Message: []byte("async log queue full, dropped " + strconv.FormatUint(dropped, 10) + " messages"),
+	}
+	return logger.getEncoder().Encode(nil, &rec)
+}
+
+func closeOutput(out io.Writer) error {
+	if out == os.Stdout || out == os.Stderr {
+		return nil
+	}
+	if closer, ok := out.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (logger *FmtBasedLogger) WithField(key string, value interface{}) Logger {
+	return logger.WithFields(Fields{key: value})
+}
+
+func (logger *FmtBasedLogger) WithFields(fields Fields) Logger {
+	child := logger.child()
+	child.fields = mergeFields(logger.fields, fields)
+	return child
+}
+
+// child returns a logger inheriting fields, name, level, sampler, flow and op
+// of logger.
+func (logger *FmtBasedLogger) child() *FmtBasedLogger {
+	child := &FmtBasedLogger{
+		parent:  logger,
+		fields:  logger.fields,
+		name:    logger.name,
+		node:    logger.node,
+		sampler: logger.sampler,
+		delta:   logger.delta,
+		flow:    logger.flow,
+		op:      logger.op,
+	}
+	if logger.root().deltaMode == DeltaLogger {
+		child.delta = NewDeltaTracker(logger.delta.Prev())
+	}
+	return child
 }
 
 func (logger *FmtBasedLogger) Trace(args ...interface{}) {
@@ -199,10 +634,12 @@ func (logger *FmtBasedLogger) Errorf(format string, args ...interface{}) {
 
 func (logger *FmtBasedLogger) Fatal(args ...interface{}) {
 	logger.WriteMessage(LogLevelFatal, time.Now(), args...)
+	logger.Flush()
 	os.Exit(1)
 }
 
 func (logger *FmtBasedLogger) Fatalf(format string, args ...interface{}) {
 	logger.WriteMessagef(LogLevelFatal, time.Now(), format, args...)
+	logger.Flush()
 	os.Exit(1)
 }
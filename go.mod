@@ -6,11 +6,11 @@ require (
 	github.com/agiledragon/gomonkey/v2 v2.4.0
 	github.com/sirupsen/logrus v1.8.1
 	github.com/stretchr/testify v1.3.0
+	golang.org/x/sys v0.0.0-20191026070338-33540a1f6037
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/stretchr/objx v0.3.0 // indirect
-	golang.org/x/sys v0.0.0-20191026070338-33540a1f6037 // indirect
 )
@@ -0,0 +1,257 @@
+package justlog
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Handler is a back-end receiving records from HandlerBasedLogger.
+// Handle must not keep rec or its slices after return.
+type Handler interface {
+	Enabled(level Level) bool
+	Handle(rec *Record) error
+}
+
+type HandlerBasedLogger struct {
+	Handler      Handler
+	ReportCaller bool
+	// ErrorFunc receives errors of Handler, they are written to stderr when
+	// it is nil. Children made by WithField share the ErrorFunc set before.
+	ErrorFunc func(err error)
+	fields    []Field
+	flow      *Flow
+}
+
+func NewHandlerBasedLogger(handler Handler) *HandlerBasedLogger {
+	return &HandlerBasedLogger{Handler: handler}
+}
+
+// handlerCallerDepth is the number of frames between runtime.Caller in
+// newRecord and the code calling a logging method.
+const handlerCallerDepth = 3
+
+// newRecord returns a pooled record with the fields every line of logger
+// has, Message and Fields are left to the caller. It must be called
+// directly from log, logf or logFields and released by putRecord.
+func (logger *HandlerBasedLogger) newRecord(level Level) *Record {
+	rec := getRecord()
+	rec.Time = time.Now()
+	rec.Level = level
+	rec.Flow = logger.flow
+	if logger.ReportCaller {
+		rec.Caller = callerString(handlerCallerDepth)
+	}
+	return rec
+}
+
+func (logger *HandlerBasedLogger) log(level Level, args ...interface{}) {
+	if !logger.Handler.Enabled(level) {
+		return
+	}
+	rec := logger.newRecord(level)
+	defer putRecord(rec)
+	msg := getMessageBuffer()
+	defer putMessageBuffer(msg)
+	rec.Message = appendMessage(msg.Bytes(), args...)
+	rec.Fields = logger.fields
+	logger.handle(rec)
+}
+
+func (logger *HandlerBasedLogger) logf(level Level, format string, args ...interface{}) {
+	if !logger.Handler.Enabled(level) {
+		return
+	}
+	rec := logger.newRecord(level)
+	defer putRecord(rec)
+	msg := getMessageBuffer()
+	defer putMessageBuffer(msg)
+	fmt.Fprintf(msg, format, args...)
+	rec.Message = msg.Bytes()
+	rec.Fields = logger.fields
+	logger.handle(rec)
+}
+
+func (logger *HandlerBasedLogger) handle(rec *Record) {
+	err := logger.Handler.Handle(rec)
+	if err == nil {
+		return
+	}
+	if logger.ErrorFunc != nil {
+		logger.ErrorFunc(err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "justlog: %v\n", err)
+}
+
+func (logger *HandlerBasedLogger) Trace(args ...interface{}) {
+	logger.log(LogLevelTrace, args...)
+}
+
+func (logger *HandlerBasedLogger) Tracef(format string, args ...interface{}) {
+	logger.logf(LogLevelTrace, format, args...)
+}
+
+func (logger *HandlerBasedLogger) Debug(args ...interface{}) {
+	logger.log(LogLevelDebug, args...)
+}
+
+func (logger *HandlerBasedLogger) Debugf(format string, args ...interface{}) {
+	logger.logf(LogLevelDebug, format, args...)
+}
+
+func (logger *HandlerBasedLogger) Info(args ...interface{}) {
+	logger.log(LogLevelInfo, args...)
+}
+
+func (logger *HandlerBasedLogger) Infof(format string, args ...interface{}) {
+	logger.logf(LogLevelInfo, format, args...)
+}
+
+func (logger *HandlerBasedLogger) Print(args ...interface{}) {
+	logger.log(LogLevelInfo, args...)
+}
+
+func (logger *HandlerBasedLogger) Printf(format string, args ...interface{}) {
+	logger.logf(LogLevelInfo, format, args...)
+}
+
+func (logger *HandlerBasedLogger) LogFields(level Level, msg string, fields ...Field) {
+	logger.logFields(level, msg, fields)
+}
+
+func (logger *HandlerBasedLogger) logFields(level Level, msg string, fields []Field) {
+	if !logger.Handler.Enabled(level) {
+		return
+	}
+	rec := logger.newRecord(level)
+	defer putRecord(rec)
+	buf := getMessageBuffer()
+	defer putMessageBuffer(buf)
+	buf.WriteString(msg)
+	rec.Message = buf.Bytes()
+	rec.setFields(logger.fields, fields)
+	logger.handle(rec)
+}
+
+func (logger *HandlerBasedLogger) Warn(args ...interface{}) {
+	logger.log(LogLevelWarn, args...)
+}
+
+func (logger *HandlerBasedLogger) Warnf(format string, args ...interface{}) {
+	logger.logf(LogLevelWarn, format, args...)
+}
+
+func (logger *HandlerBasedLogger) Error(args ...interface{}) {
+	logger.log(LogLevelError, args...)
+}
+
+func (logger *HandlerBasedLogger) Errorf(format string, args ...interface{}) {
+	logger.logf(LogLevelError, format, args...)
+}
+
+func (logger *HandlerBasedLogger) Fatal(args ...interface{}) {
+	logger.log(LogLevelFatal, args...)
+	logger.Flush()
+	os.Exit(1)
+}
+
+func (logger *HandlerBasedLogger) Fatalf(format string, args ...interface{}) {
+	logger.logf(LogLevelFatal, format, args...)
+	logger.Flush()
+	os.Exit(1)
+}
+
+// Flusher is implemented by handlers holding records back, like TeeHandler
+// with queued sinks or FmtBasedLogger in async mode.
+type Flusher interface {
+	Flush() error
+}
+
+// Flush flushes Handler if it is a Flusher. Fatal calls it before exit.
+func (logger *HandlerBasedLogger) Flush() error {
+	if flusher, ok := logger.Handler.(Flusher); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+func (logger *HandlerBasedLogger) WithField(key string, value interface{}) Logger {
+	return logger.WithFields(Fields{key: value})
+}
+
+func (logger *HandlerBasedLogger) WithFields(fields Fields) Logger {
+	return &HandlerBasedLogger{
+		Handler:      logger.Handler,
+		ReportCaller: logger.ReportCaller,
+		ErrorFunc:    logger.ErrorFunc,
+		fields:       mergeFields(logger.fields, fields),
+		flow:         logger.flow,
+	}
+}
+
+// LevelHandler passes to Handler records of Level and above.
+type LevelHandler struct {
+	Level   Level
+	Handler Handler
+}
+
+func (h *LevelHandler) Enabled(level Level) bool {
+	return h.Level <= level && h.Handler.Enabled(level)
+}
+
+func (h *LevelHandler) Handle(rec *Record) error {
+	if h.Level > rec.Level {
+		return nil
+	}
+	return h.Handler.Handle(rec)
+}
+
+func (h *LevelHandler) Flush() error {
+	if flusher, ok := h.Handler.(Flusher); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+// MultiHandler passes each record to every enabled handler. All handlers are
+// called even if some of them fail, the first error is returned.
+type MultiHandler []Handler
+
+func (h MultiHandler) Enabled(level Level) bool {
+	for _, handler := range h {
+		if handler.Enabled(level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h MultiHandler) Handle(rec *Record) error {
+	var firstErr error
+	for _, handler := range h {
+		if !handler.Enabled(rec.Level) {
+			continue
+		}
+		if err := handler.Handle(rec); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Flush flushes every handler that is a Flusher, the first error is
+// returned.
+func (h MultiHandler) Flush() error {
+	var firstErr error
+	for _, handler := range h {
+		flusher, ok := handler.(Flusher)
+		if !ok {
+			continue
+		}
+		if err := flusher.Flush(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
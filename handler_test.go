@@ -0,0 +1,162 @@
+package justlog
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type testRecord struct {
+	Level   Level
+	Message string
+	Fields  []Field
+}
+
+type testHandler struct {
+	Level   Level
+	Err     error
+	Records []testRecord
+}
+
+func (h *testHandler) Enabled(level Level) bool {
+	return h.Level <= level
+}
+
+func (h *testHandler) Handle(rec *Record) error {
+	h.Records = append(h.Records, testRecord{
+		Level:   rec.Level,
+		Message: string(rec.Message),
+		Fields:  append([]Field(nil), rec.Fields...),
+	})
+	return h.Err
+}
+
+func Test_HandlerBasedLogger_Records(t *testing.T) {
+	h := &testHandler{Level: LogLevelDebug}
+	logger := NewHandlerBasedLogger(h)
+
+	logger.Trace("filtered")
+	logger.Debug("debug ", 1)
+	logger.WithField("k", "v").Infof("info %d", 2)
+	logger.Print("print")
+
+	assert.Equal(t, []testRecord{
+		{Level: LogLevelDebug, Message: "debug 1"},
+		{Level: LogLevelInfo, Message: "info 2", Fields: []Field{{Key: "k", Value: "v"}}},
+		{Level: LogLevelInfo, Message: "print"},
+	}, h.Records)
+}
+
+func Test_HandlerBasedLogger_ErrorFunc(t *testing.T) {
+	h := &testHandler{Err: errors.New("send failed")}
+	logger := NewHandlerBasedLogger(h)
+	var errs []error
+	logger.ErrorFunc = func(err error) { errs = append(errs, err) }
+
+	logger.Info("one")
+	logger.WithField("k", "v").LogFields(LogLevelWarn, "two")
+	logger.WithFlow(NewFlow(time.Now())).Errorf("three")
+	assert.Equal(t, []error{h.Err, h.Err, h.Err}, errs)
+}
+
+func Test_HandlerBasedLogger_Fatal(t *testing.T) {
+	patches := gomonkey.NewPatches()
+	defer patches.Reset()
+
+	exitCount := 0
+	patches.ApplyFunc(os.Exit, func(code int) {
+		assert.Equal(t, 1, code)
+		exitCount++
+	})
+
+	h := &testHandler{}
+	logger := NewHandlerBasedLogger(h)
+	logger.Fatalf("fatal %s", "error")
+
+	assert.Equal(t, 1, exitCount, "os.Exit(1) calls")
+	assert.Equal(t, []testRecord{{Level: LogLevelFatal, Message: "fatal error"}}, h.Records)
+}
+
+func Test_HandlerBasedLogger_FmtBasedLoggerHandler(t *testing.T) {
+	patches := gomonkey.NewPatches()
+	defer patches.Reset()
+	patches.ApplyFuncSeq(time.Now, []gomonkey.OutputCell{
+		{Values: gomonkey.Params{time.Date(2021, time.Month(2), 1, 3, 4, 3, 8000000, time.UTC)}},
+		{Values: gomonkey.Params{time.Date(2021, time.Month(2), 1, 3, 4, 5, 9000000, time.UTC)}},
+	})
+
+	backend, err := NewFmtBasedLogger(LoggerConfig{Level: "info"})
+	assert.NoError(t, err)
+	var out strings.Builder
+	backend.SetOutput(&out)
+
+	logger := NewHandlerBasedLogger(backend)
+	logger.Debug("filtered by backend")
+	logger.WithFields(Fields{"user": "joe"}).Warnf("log %s", "message")
+
+	assert.Equal(t, "2021-02-01 03:04:05.009000[+2.001000] [WRN] log message user=joe\n", out.String())
+}
+
+func Test_HandlerBasedLogger_ReportCaller(t *testing.T) {
+	backend, err := NewFmtBasedLogger(LoggerConfig{ShowNoTime: true})
+	assert.NoError(t, err)
+	var out strings.Builder
+	backend.SetOutput(&out)
+
+	logger := NewHandlerBasedLogger(backend)
+	logger.ReportCaller = true
+	logger.Info("plain")
+	logger.Infof("with %s", "format")
+	logger.LogFields(LogLevelInfo, "fields", Int("n", 1))
+	assert.Regexp(t, `^\[\+\d+\.\d{6}\] \[INF\] handler_test\.go:\d+: plain\n`+
+		`\[\+\d+\.\d{6}\] \[INF\] handler_test\.go:\d+: with format\n`+
+		`\[\+\d+\.\d{6}\] \[INF\] handler_test\.go:\d+: fields n=1\n$`, out.String())
+}
+
+func Test_HandlerBasedLogger_LogrusBasedLoggerHandler(t *testing.T) {
+	backend, err := NewLogrusLogger(LoggerConfig{Level: "info", ShowNoTime: true, Encoding: EncodingLogfmt})
+	assert.NoError(t, err)
+	var out strings.Builder
+	backend.SetOutput(&out)
+
+	logger := NewHandlerBasedLogger(backend)
+	logger.Debug("filtered by backend")
+	logger.WithFields(Fields{"user": "joe"}).Errorf("log %s", "message")
+
+	assert.Regexp(t, `^delta=\d+\.\d{6} level=error msg="log message" user=joe\n$`, out.String())
+}
+
+func Test_LevelHandler(t *testing.T) {
+	h := &testHandler{}
+	logger := NewHandlerBasedLogger(&LevelHandler{Level: LogLevelWarn, Handler: h})
+
+	logger.Info("filtered")
+	logger.Warn("passed")
+
+	assert.Equal(t, []testRecord{{Level: LogLevelWarn, Message: "passed"}}, h.Records)
+}
+
+func Test_MultiHandler(t *testing.T) {
+	failing := &testHandler{Level: LogLevelError, Err: errors.New("broken")}
+	all := &testHandler{Level: LogLevelTrace}
+	multi := MultiHandler{failing, all}
+
+	assert.True(t, multi.Enabled(LogLevelTrace))
+
+	logger := NewHandlerBasedLogger(multi)
+	logger.Info("info")
+	logger.Error("error")
+
+	assert.Equal(t, []testRecord{{Level: LogLevelError, Message: "error"}}, failing.Records)
+	assert.Equal(t, []testRecord{
+		{Level: LogLevelInfo, Message: "info"},
+		{Level: LogLevelError, Message: "error"},
+	}, all.Records)
+
+	assert.EqualError(t, multi.Handle(&Record{Level: LogLevelError}), "broken")
+}
@@ -0,0 +1,107 @@
+package justlog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrVeto returned by Hook.Fire, possibly wrapped, drops the line without
+// reporting an error.
+var ErrVeto = errors.New("line vetoed by hook")
+
+var AllLevels = []Level{
+	LogLevelTrace,
+	LogLevelDebug,
+	LogLevelInfo,
+	LogLevelWarn,
+	LogLevelError,
+	LogLevelFatal,
+}
+
+// Hook is called by FmtBasedLogger for lines of its levels after level
+// filtering and before the line is written. Fire may change the record,
+// use SetField and DeleteField for fields, but must not keep it or its
+// slices after return.
+type Hook interface {
+	Levels() []Level
+	Fire(rec *Record) error
+}
+
+type levelHooks [LogLevelFatal + 1][]Hook
+
+// hookSet is the copy-on-write storage of hooks of the root logger, read
+// without locking on every line.
+type hookSet struct {
+	mu    sync.Mutex
+	hooks atomic.Value // *levelHooks
+}
+
+// AddHook registers hook for all children of the root logger.
+func (logger *FmtBasedLogger) AddHook(hook Hook) {
+	set := &logger.root().hooks
+	set.mu.Lock()
+	defer set.mu.Unlock()
+
+	var hooks levelHooks
+	if old, ok := set.hooks.Load().(*levelHooks); ok {
+		hooks = *old
+	}
+	for _, level := range hook.Levels() {
+		if level > LogLevelFatal {
+			continue
+		}
+		hooks[level] = append(hooks[level][:len(hooks[level]):len(hooks[level])], hook)
+	}
+	set.hooks.Store(&hooks)
+}
+
+// fireHooks tells whether the line should be written. Errors other than
+// ErrVeto are passed to ErrorFunc of the root logger.
+func (logger *FmtBasedLogger) fireHooks(rec *Record) bool {
+	root := logger.root()
+	hooks, ok := root.hooks.hooks.Load().(*levelHooks)
+	if !ok || rec.Level > LogLevelFatal {
+		return true
+	}
+	for _, hook := range hooks[rec.Level] {
+		err := hook.Fire(rec)
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, ErrVeto) {
+			return false
+		}
+		root.reportError(fmt.Errorf("hook %T: %w", hook, err))
+	}
+	return true
+}
+
+// reportError passes err to ErrorFunc or writes it to stderr when ErrorFunc
+// is not set.
+func (logger *FmtBasedLogger) reportError(err error) {
+	if logger.ErrorFunc != nil {
+		logger.ErrorFunc(err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "justlog: %v\n", err)
+}
+
+// SetField sets field value, replacing the field with the same key. Fields
+// slice is copied, as it is shared with the logger.
+func (rec *Record) SetField(key string, value interface{}) {
+	rec.Fields = mergeFields(rec.Fields, Fields{key: value})
+}
+
+func (rec *Record) DeleteField(key string) {
+	for i := range rec.Fields {
+		if rec.Fields[i].Key == key {
+			fields := make([]Field, 0, len(rec.Fields)-1)
+			fields = append(fields, rec.Fields[:i]...)
+			rec.Fields = append(fields, rec.Fields[i+1:]...)
+			return
+		}
+	}
+}
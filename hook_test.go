@@ -0,0 +1,97 @@
+package justlog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testHook struct {
+	levels []Level
+	fire   func(rec *Record) error
+	fired  int
+}
+
+func (h *testHook) Levels() []Level {
+	return h.levels
+}
+
+func (h *testHook) Fire(rec *Record) error {
+	h.fired++
+	return h.fire(rec)
+}
+
+func Test_FmtBasedLogger_AddHook(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{ShowNoTime: true, Level: "debug"})
+	assert.NoError(t, err)
+	var out strings.Builder
+	logger.SetOutput(&out)
+	var hookErrs []string
+	logger.ErrorFunc = func(err error) {
+		hookErrs = append(hookErrs, err.Error())
+	}
+
+	redact := &testHook{levels: AllLevels, fire: func(rec *Record) error {
+		rec.DeleteField("password")
+		rec.SetField("host", "web1")
+		return nil
+	}}
+	veto := &testHook{levels: []Level{LogLevelDebug}, fire: func(rec *Record) error {
+		if strings.HasPrefix(string(rec.Message), "noisy") {
+			return fmt.Errorf("debug: %w", ErrVeto)
+		}
+		return nil
+	}}
+	broken := &testHook{levels: []Level{LogLevelError}, fire: func(rec *Record) error {
+		return errors.New("alert failed")
+	}}
+	logger.AddHook(redact)
+	logger.AddHook(veto)
+	logger.AddHook(broken)
+
+	child := logger.WithFields(Fields{"user": "joe", "password": "secret"})
+	child.Trace("filtered before hooks")
+	child.Debug("noisy poll")
+	child.Debugf("query %d", 1)
+	child.Error("failed")
+
+	assert.Equal(t, " [DBG] query 1 user=joe host=web1\n [ERR] failed user=joe host=web1\n", stripDelta(out.String()))
+	assert.Equal(t, 3, redact.fired)
+	assert.Equal(t, 2, veto.fired)
+	assert.Equal(t, []string{"hook *justlog.testHook: alert failed"}, hookErrs)
+	assert.Equal(t, []Field{{Key: "password", Value: "secret"}, {Key: "user", Value: "joe"}},
+		child.(*FmtBasedLogger).fields, "hooks do not change fields of the logger")
+}
+
+func stripDelta(out string) string {
+	lines := strings.SplitAfter(out, "\n")
+	for i, line := range lines {
+		if end := strings.Index(line, "]"); strings.HasPrefix(line, "[+") && end > 0 {
+			lines[i] = line[end+1:]
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+func Test_FmtBasedLogger_Handle_FiresHooks(t *testing.T) {
+	backend, err := NewFmtBasedLogger(LoggerConfig{ShowNoTime: true})
+	assert.NoError(t, err)
+	var out strings.Builder
+	backend.SetOutput(&out)
+	backend.AddHook(&testHook{levels: AllLevels, fire: func(rec *Record) error {
+		if string(rec.Message) == "vetoed" {
+			return ErrVeto
+		}
+		rec.SetField("host", "web1")
+		return nil
+	}})
+
+	logger := NewHandlerBasedLogger(backend)
+	logger.Info("vetoed")
+	logger.WithField("user", "joe").Info("passed")
+
+	assert.Equal(t, " [INF] passed user=joe host=web1\n", stripDelta(out.String()))
+}
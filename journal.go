@@ -0,0 +1,91 @@
+package justlog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strconv"
+	"strings"
+)
+
+const DefaultJournalSocket = "/run/systemd/journal/socket"
+
+type JournalConfig struct {
+	// Socket is the journald native protocol socket, DefaultJournalSocket
+	// when empty.
+	Socket     string
+	Identifier string
+	Level      string
+}
+
+// journalFieldName converts field key to a valid journal field name:
+// uppercase letters, digits and underscores, not starting with underscore
+// or digit. Names the handler writes itself get F_ prefix too. Empty string
+// is returned for keys with nothing usable.
+func journalFieldName(key string) string {
+	name := make([]byte, 0, len(key))
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			c -= 'a' - 'A'
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		default:
+			c = '_'
+		}
+		if c == '_' && len(name) == 0 {
+			continue
+		}
+		name = append(name, c)
+	}
+	if len(name) > 0 && name[0] >= '0' && name[0] <= '9' || journalReservedName(name) {
+		name = append([]byte{'F', '_'}, name...)
+	}
+	return string(name)
+}
+
+func journalReservedName(name []byte) bool {
+	switch string(name) {
+	case "MESSAGE", "PRIORITY", "SYSLOG_IDENTIFIER":
+		return true
+	}
+	return bytes.HasPrefix(name, []byte("CODE_"))
+}
+
+// appendJournalField appends one field in journal native protocol format.
+// Values with newlines use the binary form with explicit length.
+func appendJournalField(buf []byte, name string, value []byte) []byte {
+	buf = append(buf, name...)
+	if bytes.IndexByte(value, '\n') < 0 {
+		buf = append(buf, '=')
+		buf = append(buf, value...)
+		return append(buf, '\n')
+	}
+	buf = append(buf, '\n')
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	buf = append(buf, size[:]...)
+	buf = append(buf, value...)
+	return append(buf, '\n')
+}
+
+func appendJournalRecord(buf []byte, rec *Record, identifier string) []byte {
+	buf = appendJournalField(buf, "PRIORITY", []byte(strconv.Itoa(syslogSeverity(rec.Level))))
+	if identifier != "" {
+		buf = appendJournalField(buf, "SYSLOG_IDENTIFIER", []byte(identifier))
+	}
+	buf = appendJournalField(buf, "MESSAGE", rec.Message)
+	if i := strings.LastIndexByte(rec.Caller, ':'); i > 0 {
+		buf = appendJournalField(buf, "CODE_FILE", []byte(rec.Caller[:i]))
+		buf = appendJournalField(buf, "CODE_LINE", []byte(rec.Caller[i+1:]))
+	}
+	var value []byte
+	for i := range rec.Fields {
+		name := journalFieldName(rec.Fields[i].Key)
+		if name == "" {
+			continue
+		}
+		value = appendField(value[:0], &rec.Fields[i])
+		buf = appendJournalField(buf, name, value)
+	}
+	return buf
+}
@@ -0,0 +1,141 @@
+//go:build linux
+// +build linux
+
+package justlog
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// JournalHandler sends records to systemd-journald with its native protocol.
+// Entries too big for a datagram are passed in a sealed memfd.
+type JournalHandler struct {
+	Level      Level
+	Identifier string
+
+	socket string
+
+	mu   sync.Mutex
+	conn *net.UnixConn
+}
+
+func NewJournalHandler(cfg JournalConfig) (*JournalHandler, error) {
+	logLevel, err := ParseLogLevel(cfg.Level)
+	if err != nil {
+		return nil, fmt.Errorf("ParseLevel error: %w", err)
+	}
+	h := &JournalHandler{
+		Level:      logLevel,
+		Identifier: cfg.Identifier,
+		socket:     cfg.Socket,
+	}
+	if h.socket == "" {
+		h.socket = DefaultJournalSocket
+	}
+	if h.Identifier == "" && len(os.Args) > 0 {
+		h.Identifier = filepath.Base(os.Args[0])
+	}
+	return h, nil
+}
+
+func (h *JournalHandler) Enabled(level Level) bool {
+	return h.Level <= level
+}
+
+func (h *JournalHandler) Handle(rec *Record) error {
+	msg := appendJournalRecord(make([]byte, 0, len(rec.Message)+128), rec, h.Identifier)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		if h.conn == nil {
+			if err := h.connect(); err != nil {
+				return err
+			}
+		}
+		err := h.send(msg)
+		if err == nil {
+			return nil
+		}
+		h.closeConn()
+		if attempt > 0 {
+			return fmt.Errorf("write to journal: %w", err)
+		}
+	}
+}
+
+func (h *JournalHandler) send(msg []byte) error {
+	_, err := h.conn.Write(msg)
+	if err == nil || !isMessageTooBig(err) {
+		return err
+	}
+	return h.sendMemfd(msg)
+}
+
+func isMessageTooBig(err error) bool {
+	return errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS)
+}
+
+func (h *JournalHandler) sendMemfd(msg []byte) error {
+	fd, err := unix.MemfdCreate("journal-message", unix.MFD_CLOEXEC|unix.MFD_ALLOW_SEALING)
+	if err != nil {
+		return fmt.Errorf("memfd_create: %w", err)
+	}
+	file := os.NewFile(uintptr(fd), "journal-message")
+	defer file.Close()
+
+	if _, err := file.Write(msg); err != nil {
+		return fmt.Errorf("write memfd: %w", err)
+	}
+	seals := unix.F_SEAL_SHRINK | unix.F_SEAL_GROW | unix.F_SEAL_WRITE | unix.F_SEAL_SEAL
+	if _, err := unix.FcntlInt(uintptr(fd), unix.F_ADD_SEALS, seals); err != nil {
+		return fmt.Errorf("seal memfd: %w", err)
+	}
+	// net refuses WriteMsgUnix on a connected datagram socket
+	rawConn, err := h.conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var sendErr error
+	err = rawConn.Write(func(sock uintptr) bool {
+		sendErr = unix.Sendmsg(int(sock), nil, unix.UnixRights(fd), nil, 0)
+		return sendErr != unix.EAGAIN
+	})
+	if err != nil {
+		return err
+	}
+	return sendErr
+}
+
+func (h *JournalHandler) connect() error {
+	addr := &net.UnixAddr{Name: h.socket, Net: "unixgram"}
+	conn, err := net.DialUnix("unixgram", nil, addr)
+	if err != nil {
+		return fmt.Errorf("connect to journal: %w", err)
+	}
+	h.conn = conn
+	return nil
+}
+
+func (h *JournalHandler) closeConn() {
+	if h.conn != nil {
+		h.conn.Close()
+		h.conn = nil
+	}
+}
+
+func (h *JournalHandler) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closeConn()
+	return nil
+}
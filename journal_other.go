@@ -0,0 +1,31 @@
+//go:build !linux
+// +build !linux
+
+package justlog
+
+import (
+	"errors"
+)
+
+var errJournalUnsupported = errors.New("journal output is supported on linux only")
+
+type JournalHandler struct {
+	Level      Level
+	Identifier string
+}
+
+func NewJournalHandler(cfg JournalConfig) (*JournalHandler, error) {
+	return nil, errJournalUnsupported
+}
+
+func (h *JournalHandler) Enabled(level Level) bool {
+	return false
+}
+
+func (h *JournalHandler) Handle(rec *Record) error {
+	return errJournalUnsupported
+}
+
+func (h *JournalHandler) Close() error {
+	return nil
+}
@@ -0,0 +1,190 @@
+//go:build linux
+// +build linux
+
+package justlog
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// parseJournalEntry decodes journal native protocol datagram into fields.
+func parseJournalEntry(t *testing.T, data []byte) map[string]string {
+	fields := make(map[string]string)
+	for len(data) > 0 {
+		nl := strings.IndexByte(string(data), '\n')
+		if !assert.True(t, nl > 0, "field terminator") {
+			return fields
+		}
+		line := string(data[:nl])
+		if eq := strings.IndexByte(line, '='); eq >= 0 {
+			fields[line[:eq]] = line[eq+1:]
+			data = data[nl+1:]
+			continue
+		}
+		data = data[nl+1:]
+		size := binary.LittleEndian.Uint64(data[:8])
+		fields[line] = string(data[8 : 8+size])
+		assert.Equal(t, byte('\n'), data[8+size])
+		data = data[8+size+1:]
+	}
+	return fields
+}
+
+type journalTestListener struct {
+	Path    string
+	conn    *net.UnixConn
+	Entries chan map[string]string
+}
+
+func listenJournal(t *testing.T, path string) *journalTestListener {
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	l := &journalTestListener{Path: path, conn: conn, Entries: make(chan map[string]string, 16)}
+	go func() {
+		buf := make([]byte, 1<<20)
+		oob := make([]byte, syscall.CmsgSpace(4))
+		for {
+			n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+			if err != nil {
+				return
+			}
+			data := append([]byte(nil), buf[:n]...)
+			if oobn > 0 {
+				data = readJournalMemfd(t, oob[:oobn])
+			}
+			l.Entries <- parseJournalEntry(t, data)
+		}
+	}()
+	return l
+}
+
+func readJournalMemfd(t *testing.T, oob []byte) []byte {
+	msgs, err := syscall.ParseSocketControlMessage(oob)
+	if !assert.NoError(t, err) || !assert.Len(t, msgs, 1) {
+		return nil
+	}
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	if !assert.NoError(t, err) || !assert.Len(t, fds, 1) {
+		return nil
+	}
+	file := os.NewFile(uintptr(fds[0]), "memfd")
+	defer file.Close()
+	// journald maps the whole file, offset left by the sender is ignored
+	info, err := file.Stat()
+	assert.NoError(t, err)
+	data, err := io.ReadAll(io.NewSectionReader(file, 0, info.Size()))
+	assert.NoError(t, err)
+	return data
+}
+
+func (l *journalTestListener) Next(t *testing.T) map[string]string {
+	select {
+	case entry := <-l.Entries:
+		return entry
+	case <-time.After(time.Second):
+		t.Error("no journal entry received")
+		return nil
+	}
+}
+
+func (l *journalTestListener) Close() {
+	l.conn.Close()
+	os.Remove(l.Path)
+}
+
+func Test_JournalHandler_Fields(t *testing.T) {
+	l := listenJournal(t, filepath.Join(t.TempDir(), "journal.sock"))
+	defer l.Close()
+
+	h, err := NewJournalHandler(JournalConfig{Socket: l.Path, Identifier: "app", Level: "debug"})
+	assert.NoError(t, err)
+	defer h.Close()
+
+	logger := NewHandlerBasedLogger(h)
+	logger.Trace("filtered")
+	logger.WithFields(Fields{
+		"user": "joe", "req.id": 42, "_private": "x", "9lives": "cat",
+		"message": "fake", "priority": 0, "syslog_identifier": "other", "code_line": 1,
+	}).Warn("multi\nline")
+
+	assert.Equal(t, map[string]string{
+		"PRIORITY":            "4",
+		"SYSLOG_IDENTIFIER":   "app",
+		"MESSAGE":             "multi\nline",
+		"USER":                "joe",
+		"REQ_ID":              "42",
+		"PRIVATE":             "x",
+		"F_9LIVES":            "cat",
+		"F_MESSAGE":           "fake",
+		"F_PRIORITY":          "0",
+		"F_SYSLOG_IDENTIFIER": "other",
+		"F_CODE_LINE":         "1",
+	}, l.Next(t))
+}
+
+func Test_JournalHandler_Caller(t *testing.T) {
+	l := listenJournal(t, filepath.Join(t.TempDir(), "journal.sock"))
+	defer l.Close()
+
+	h, err := NewJournalHandler(JournalConfig{Socket: l.Path, Identifier: "app"})
+	assert.NoError(t, err)
+	defer h.Close()
+
+	assert.NoError(t, h.Handle(&Record{Level: LogLevelError, Message: []byte("failed"), Caller: "main.go:12"}))
+	entry := l.Next(t)
+	assert.Equal(t, "3", entry["PRIORITY"])
+	assert.Equal(t, "main.go", entry["CODE_FILE"])
+	assert.Equal(t, "12", entry["CODE_LINE"])
+}
+
+func Test_JournalHandler_MemfdForOversizedEntry(t *testing.T) {
+	l := listenJournal(t, filepath.Join(t.TempDir(), "journal.sock"))
+	defer l.Close()
+
+	h, err := NewJournalHandler(JournalConfig{Socket: l.Path, Identifier: "app"})
+	assert.NoError(t, err)
+	defer h.Close()
+
+	big := strings.Repeat("x", 4<<20)
+	assert.NoError(t, h.Handle(&Record{Level: LogLevelInfo, Message: []byte(big)}))
+	entry := l.Next(t)
+	assert.Equal(t, "6", entry["PRIORITY"])
+	assert.Equal(t, len(big), len(entry["MESSAGE"]))
+}
+
+func Test_JournalHandler_Reconnect(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	l := listenJournal(t, path)
+
+	h, err := NewJournalHandler(JournalConfig{Socket: path, Identifier: "app"})
+	assert.NoError(t, err)
+	defer h.Close()
+
+	assert.NoError(t, h.Handle(&Record{Level: LogLevelInfo, Message: []byte("first")}))
+	assert.Equal(t, "first", l.Next(t)["MESSAGE"])
+
+	l.Close()
+	l = listenJournal(t, path)
+	defer l.Close()
+
+	assert.NoError(t, h.Handle(&Record{Level: LogLevelInfo, Message: []byte("second")}))
+	assert.Equal(t, "second", l.Next(t)["MESSAGE"])
+}
+
+func Test_JournalHandler_NoSocket(t *testing.T) {
+	h, err := NewJournalHandler(JournalConfig{Socket: filepath.Join(t.TempDir(), "missing.sock")})
+	assert.NoError(t, err)
+	assert.Error(t, h.Handle(&Record{Level: LogLevelInfo, Message: []byte("lost")}))
+}
@@ -0,0 +1,230 @@
+package justlog
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+const hexDigits = "0123456789abcdef"
+
+type JSONEncoder struct {
+	TimeFormat  string
+	ShowNoTime  bool
+	ShowNoDelta bool
+}
+
+func NewJSONEncoder(cfg *LoggerConfig, deltaMode DeltaMode) *JSONEncoder {
+	enc := &JSONEncoder{TimeFormat: DefaultTimeFormat, ShowNoDelta: deltaMode == DeltaNone}
+	if cfg == nil {
+		return enc
+	}
+	if cfg.TimeFormat != "" {
+		enc.TimeFormat = cfg.TimeFormat
+	}
+	enc.ShowNoTime = cfg.ShowNoTime
+	return enc
+}
+
+func (enc *JSONEncoder) Encode(buf []byte, rec *Record) []byte {
+	buf = append(buf, '{')
+	if !enc.ShowNoTime {
+		buf = append(buf, `"time":"`...)
+		mark := len(buf)
+		buf = appendTimeFormat(buf, rec.Time, enc.TimeFormat)
+		if jsonNeedsEscape(buf[mark:]) {
+			formatted := append([]byte(nil), buf[mark:]...)
+			buf = appendJSONEscaped(buf[:mark], formatted)
+		}
+		buf = append(buf, `",`...)
+	}
+	if !enc.ShowNoDelta {
+		buf = append(buf, `"delta":`...)
+		buf = appendSeconds(buf, rec.Delta)
+		buf = append(buf, ',')
+		if rec.Flow != nil {
+			buf = append(buf, `"elapsed":`...)
+			buf = appendSeconds(buf, rec.Elapsed)
+			buf = append(buf, ',')
+		}
+	}
+	buf = append(buf, `"level":"`...)
+	buf = append(buf, rec.Level.String()...)
+	buf = append(buf, '"')
+	if rec.Name != "" {
+		buf = append(buf, `,"logger":`...)
+		buf = appendJSONString(buf, rec.Name)
+	}
+	if rec.Caller != "" {
+		buf = append(buf, `,"caller":`...)
+		buf = appendJSONString(buf, rec.Caller)
+	}
+	buf = append(buf, `,"msg":"`...)
+	buf = appendJSONEscaped(buf, rec.Message)
+	buf = append(buf, '"')
+	buf = appendFieldsJSON(buf, rec.Fields)
+	buf = append(buf, '}', '\n')
+	return buf
+}
+
+// appendJSONEscaped appends s escaped for use inside a JSON string literal.
+// Quotes around the value are up to the caller.
+func appendJSONEscaped(buf []byte, s []byte) []byte {
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				buf = append(buf, '\\', c)
+			case c == '\n':
+				buf = append(buf, '\\', 'n')
+			case c == '\r':
+				buf = append(buf, '\\', 'r')
+			case c == '\t':
+				buf = append(buf, '\\', 't')
+			case c < 0x20:
+				buf = append(buf, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+			default:
+				buf = append(buf, c)
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRune(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, `�`...)
+		} else {
+			buf = append(buf, s[i:i+size]...)
+		}
+		i += size
+	}
+	return buf
+}
+
+func jsonNeedsEscape(s []byte) bool {
+	for _, c := range s {
+		if c < 0x20 || c == '"' || c == '\\' || c >= utf8.RuneSelf {
+			return true
+		}
+	}
+	return false
+}
+
+func appendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	buf = appendJSONEscaped(buf, []byte(s))
+	return append(buf, '"')
+}
+
+func appendJSONFloat(buf []byte, f float64) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		buf = append(buf, '"')
+		buf = strconv.AppendFloat(buf, f, 'g', -1, 64)
+		return append(buf, '"')
+	}
+	return strconv.AppendFloat(buf, f, 'g', -1, 64)
+}
+
+func appendJSONValue(buf []byte, value interface{}) []byte {
+	switch v := value.(type) {
+	case nil:
+		return append(buf, "null"...)
+	case string:
+		return appendJSONString(buf, v)
+	case []byte:
+		buf = append(buf, '"')
+		buf = appendJSONEscaped(buf, v)
+		return append(buf, '"')
+	case bool:
+		return strconv.AppendBool(buf, v)
+	case int:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int8:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int16:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int32:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(buf, v, 10)
+	case uint:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint8:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint16:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint32:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint64:
+		return strconv.AppendUint(buf, v, 10)
+	case float32:
+		return appendJSONFloat(buf, float64(v))
+	case float64:
+		return appendJSONFloat(buf, v)
+	case time.Duration:
+		return appendJSONString(buf, v.String())
+	case error:
+		return appendJSONString(buf, v.Error())
+	case fmt.Stringer:
+		return appendJSONString(buf, v.String())
+	}
+	return appendJSONString(buf, fmt.Sprintf("%+v", value))
+}
+
+func appendJSONField(buf []byte, field *Field) []byte {
+	switch field.kind {
+	case fieldString:
+		return appendJSONString(buf, field.str)
+	case fieldInt, fieldUint, fieldBool:
+		return appendField(buf, field)
+	case fieldFloat:
+		return appendJSONFloat(buf, math.Float64frombits(uint64(field.num)))
+	case fieldDuration, fieldTime, fieldStringer:
+		buf = append(buf, '"')
+		mark := len(buf)
+		buf = appendField(buf, field)
+		if jsonNeedsEscape(buf[mark:]) {
+			escaped := appendJSONEscaped(nil, buf[mark:])
+			buf = append(buf[:mark], escaped...)
+		}
+		return append(buf, '"')
+	case fieldBytes:
+		buf = append(buf, '"')
+		buf = appendJSONEscaped(buf, field.bytes)
+		return append(buf, '"')
+	case fieldError:
+		if field.Value == nil {
+			return append(buf, "null"...)
+		}
+	}
+	return appendJSONValue(buf, field.Value)
+}
+
+// jsonReservedKey reports keys of members JSONEncoder writes itself.
+func jsonReservedKey(key string) bool {
+	switch key {
+	case "time", "delta", "elapsed", "level", "logger", "caller", "msg":
+		return true
+	}
+	return false
+}
+
+// appendFieldsJSON writes fields with reserved keys as "fields.<key>", as
+// logrus does, so they do not duplicate built-in members.
+func appendFieldsJSON(buf []byte, fields []Field) []byte {
+	for i := range fields {
+		buf = append(buf, ',')
+		if jsonReservedKey(fields[i].Key) {
+			buf = append(buf, `"fields.`...)
+			buf = append(buf, fields[i].Key...)
+			buf = append(buf, '"')
+		} else {
+			buf = appendJSONString(buf, fields[i].Key)
+		}
+		buf = append(buf, ':')
+		buf = appendJSONField(buf, &fields[i])
+	}
+	return buf
+}
@@ -0,0 +1,99 @@
+package justlog
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FmtBasedLogger_Infof_EncodingJSON(t *testing.T) {
+	TestCase_Logger{
+		Calls: TestLoggerCalls{
+			{Method: "Infof", Format: "log %s", Args: []interface{}{"message"}},
+		},
+		Config: &LoggerConfig{
+			Encoding: EncodingJSON,
+		},
+		TimeSequence: []time.Time{
+			time.Date(2021, time.Month(2), 1, 3, 4, 3, 8000000, time.UTC),
+			time.Date(2021, time.Month(2), 1, 3, 4, 5, 9000000, time.UTC),
+		},
+		WantOutput: `{"time":"2021-02-01 03:04:05.009000","delta":2.001000,"level":"info","msg":"log message"}` + "\n",
+	}.Run(t)
+}
+
+func Test_FmtBasedLogger_Warn_EncodingJSON_FieldsShowNoTime(t *testing.T) {
+	TestCase_Logger{
+		Calls: TestLoggerCalls{
+			{
+				Method: "Warn",
+				Args:   []interface{}{"say \"hi\"\n"},
+				Fields: Fields{"err": errors.New("boom"), "n": 3, "ok": true, "ratio": 0.5, "nil": nil},
+			},
+		},
+		Config: &LoggerConfig{
+			Encoding:   EncodingJSON,
+			ShowNoTime: true,
+		},
+		TimeSequence: []time.Time{
+			time.Date(2021, time.Month(2), 1, 3, 4, 3, 8000000, time.UTC),
+			time.Date(2021, time.Month(2), 1, 3, 4, 5, 9000000, time.UTC),
+		},
+		WantOutput: `{"delta":2.001000,"level":"warn","msg":"say \"hi\"\n","err":"boom","n":3,"nil":null,"ok":true,"ratio":0.5}` + "\n",
+	}.Run(t)
+}
+
+func Test_FmtBasedLogger_Info_EncodingJSON_ReservedFields(t *testing.T) {
+	TestCase_Logger{
+		Calls: TestLoggerCalls{
+			{
+				Method: "Info",
+				Args:   []interface{}{"hi"},
+				Fields: Fields{"level": "fatal", "msg": "fake", "user": "joe"},
+			},
+		},
+		Config: &LoggerConfig{
+			Encoding:   EncodingJSON,
+			ShowNoTime: true,
+		},
+		TimeSequence: []time.Time{
+			time.Date(2021, time.Month(2), 1, 3, 4, 3, 8000000, time.UTC),
+			time.Date(2021, time.Month(2), 1, 3, 4, 5, 9000000, time.UTC),
+		},
+		WantOutput: `{"delta":2.001000,"level":"info","msg":"hi","fields.level":"fatal","fields.msg":"fake","user":"joe"}` + "\n",
+	}.Run(t)
+}
+
+func Test_NewFmtBasedLogger_InvalidEncoding(t *testing.T) {
+	_, err := NewFmtBasedLogger(LoggerConfig{Encoding: "yaml"})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `"yaml"`)
+	}
+}
+
+func Test_appendJSONEscaped(t *testing.T) {
+	for in, want := range map[string]string{
+		"plain":        "plain",
+		"q\"b\\":       `q\"b\\`,
+		"nl\nrt\r\ttb": `nl\nrt\r\ttb`,
+		"ctl\x01":      `ctl\u0001`,
+		"юникод":       "юникод",
+		"bad\xff":      "bad�",
+	} {
+		assert.Equal(t, want, string(appendJSONEscaped(nil, []byte(in))), in)
+	}
+}
+
+func Test_appendSeconds(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                                "0.000000",
+		2*time.Second + time.Millisecond: "2.001000",
+		1500 * time.Nanosecond:           "0.000002",
+		-3 * time.Millisecond:            "-0.003000",
+		90 * time.Second:                 "90.000000",
+	} {
+		assert.Equal(t, want, string(appendSeconds(nil, d)), d.String())
+	}
+}
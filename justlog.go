@@ -3,6 +3,7 @@ package justlog
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -39,6 +40,8 @@ func logLevelStringLocal(lvl Level) []byte {
 		return stringLevelDebug
 	case LogLevelInfo:
 		return stringLevelInfo
+	case LogLevelWarn:
+		return stringLevelWarn
 	case LogLevelError:
 		return stringLevelError
 	case LogLevelFatal:
@@ -47,6 +50,24 @@ func logLevelStringLocal(lvl Level) []byte {
 	return stringLevelWTF
 }
 
+func (lvl Level) String() string {
+	switch lvl {
+	case LogLevelTrace:
+		return "trace"
+	case LogLevelDebug:
+		return "debug"
+	case LogLevelInfo:
+		return "info"
+	case LogLevelWarn:
+		return "warn"
+	case LogLevelError:
+		return "error"
+	case LogLevelFatal:
+		return "fatal"
+	}
+	return "invalid"
+}
+
 func ParseLogLevel(strLevel string) (Level, error) {
 	switch strLevel {
 	case "trace":
@@ -69,10 +90,64 @@ func Die(format string, args ...interface{}) {
 	logrus.Fatalf(format, args...)
 }
 
+const (
+	EncodingText   = "text"
+	EncodingJSON   = "json"
+	EncodingLogfmt = "logfmt"
+)
+
 type LoggerConfig struct {
+	// Level is a level spec like "info,db=debug", see ParseLevelSpec. The
+	// logrus backend uses only its default level. Environment variable named
+	// by LevelEnv overrides it when set.
 	Level      string
+	LevelEnv   string
 	TimeFormat string
 	ShowNoTime bool
+	// DeltaMode is one of "global" (the default), "logger", "start" or
+	// "none", see DeltaMode.
+	DeltaMode    string
+	Encoding     string
+	ReportCaller bool
+	// Color is one of ColorNever (the default), ColorAuto or ColorAlways,
+	// it applies to text encoding only. In auto mode FORCE_COLOR and
+	// NO_COLOR environment variables are honored.
+	Color string
+
+	// AsyncQueueSize above zero makes the logger write through AsyncWriter
+	// with a queue of that many lines.
+	AsyncQueueSize      int
+	AsyncDropPolicy     string
+	AsyncReportInterval time.Duration
+
+	// SampleFirst above zero turns sampling on for lines of SampleLevel and
+	// lower, see Sampler.
+	SampleFirst      int
+	SampleThereafter int
+	SampleInterval   time.Duration
+	SampleLevel      string
+	SampleByCaller   bool
+
+	// Dedup collapses repeated lines into "last message repeated N times".
+	// The count is written after DedupTimeout even if no other line comes.
+	Dedup        bool
+	DedupTimeout time.Duration
+
+	// OpLevel is the level of lines logged by Op.End, "info" by default.
+	// Ops lasting longer than OpThreshold are logged at Warn level.
+	OpLevel     string
+	OpThreshold time.Duration
+
+	// File makes the logger write to this path instead of stderr, see
+	// RotatingFileWriter for the rest of File* settings.
+	File               string
+	FileMaxSize        int64
+	FileRotate         string
+	FileMaxBackups     int
+	FileMaxAge         time.Duration
+	FileMaxTotalSize   int64
+	FileCompress       bool
+	FileReopenOnSIGHUP bool
 }
 
 type Logger interface {
@@ -90,6 +165,11 @@ type Logger interface {
 	Fatalf(format string, args ...interface{})
 	Print(args ...interface{})
 	Printf(format string, args ...interface{})
+	// LogFields logs msg with fields added to the fields of the logger. It
+	// does not exit on LogLevelFatal, unlike Fatal.
+	LogFields(level Level, msg string, fields ...Field)
+	WithField(key string, value interface{}) Logger
+	WithFields(fields Fields) Logger
 }
 
 //func NewLogger(cfg LoggerConfig) (*LogrusBasedLogger, error) {
@@ -145,3 +225,14 @@ func (logger *NoopLogger) Fatal(args ...interface{}) {
 func (logger *NoopLogger) Fatalf(format string, args ...interface{}) {
 	os.Exit(1)
 }
+
+func (logger *NoopLogger) WithField(key string, value interface{}) Logger {
+	return logger
+}
+
+func (logger *NoopLogger) WithFields(fields Fields) Logger {
+	return logger
+}
+
+func (logger *NoopLogger) LogFields(level Level, msg string, fields ...Field) {
+}
@@ -2,8 +2,10 @@ package justlog
 
 import (
 	"bytes"
+	"io"
 	"log"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -44,6 +46,45 @@ func BenchmarkFmtBasedLogger(b *testing.B) {
 	}
 }
 
+func BenchmarkFmtBasedLoggerJSON(b *testing.B) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{Encoding: EncodingJSON})
+	if err != nil {
+		b.Fail()
+		return
+	}
+
+	var out bytes.Buffer
+	logger.SetOutput(&out)
+
+	for i := 0; i < b.N; i++ {
+		logger.Tracef("format %s", "trace")
+		logger.Debugf("format %s", "debug")
+		logger.Infof("format %s", "info")
+		logger.Warnf("format %s", "warn")
+		logger.Errorf("format %s", "error")
+	}
+}
+
+func BenchmarkHandlerBasedLogger(b *testing.B) {
+	backend, err := NewFmtBasedLogger(LoggerConfig{})
+	if err != nil {
+		b.Fail()
+		return
+	}
+
+	var out bytes.Buffer
+	backend.SetOutput(&out)
+	logger := NewHandlerBasedLogger(backend)
+
+	for i := 0; i < b.N; i++ {
+		logger.Tracef("format %s", "trace")
+		logger.Debugf("format %s", "debug")
+		logger.Infof("format %s", "info")
+		logger.Warnf("format %s", "warn")
+		logger.Errorf("format %s", "error")
+	}
+}
+
 func BenchmarkLogrusBasedLogger(b *testing.B) {
 	logger, err := NewLogrusLogger(LoggerConfig{})
 	if err != nil {
@@ -116,3 +157,53 @@ func BenchmarkGolangLogStd(b *testing.B) {
 		logger.Printf("format %s", "error")
 	}
 }
+
+func BenchmarkFmtBasedLoggerTypedFields(b *testing.B) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{})
+	if err != nil {
+		b.Fail()
+		return
+	}
+
+	logger.SetOutput(io.Discard)
+	started := time.Date(2022, time.Month(2), 13, 10, 0, 0, 0, time.UTC)
+	payload := []byte("payload")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger.LogFields(LogLevelInfo, "request done",
+			String("method", "GET"),
+			Int("status", 200),
+			Int64("size", 1<<20),
+			Uint("id", 42),
+			Float64("ratio", 0.25),
+			Bool("cached", true),
+			Duration("took", 1500*time.Microsecond),
+			Time("started", started),
+			Bytes("body", payload),
+			Err(nil),
+		)
+	}
+}
+
+func BenchmarkFmtBasedLoggerTypedFieldsJSON(b *testing.B) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{Encoding: EncodingJSON})
+	if err != nil {
+		b.Fail()
+		return
+	}
+
+	logger.SetOutput(io.Discard)
+	started := time.Date(2022, time.Month(2), 13, 10, 0, 0, 0, time.UTC)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger.LogFields(LogLevelInfo, "request done",
+			String("method", "GET"),
+			Int("status", 200),
+			Float64("ratio", 0.25),
+			Duration("took", 1500*time.Microsecond),
+			Time("started", started),
+		)
+	}
+}
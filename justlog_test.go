@@ -14,6 +14,7 @@ type TestLoggerCall struct {
 	Method string
 	Format string
 	Args   []interface{}
+	Fields Fields
 }
 type TestLoggerCalls []TestLoggerCall
 
@@ -54,6 +55,10 @@ func (tc TestCase_Logger) Run(t *testing.T) {
 	logger.SetOutput(&out)
 
 	for _, call := range tc.Calls {
+		var logger Logger = logger
+		if call.Fields != nil {
+			logger = logger.WithFields(call.Fields)
+		}
 		switch call.Method {
 		case "Trace":
 			logger.Trace(call.Args...)
@@ -227,6 +232,20 @@ func Test_LogrusBasedLogger_Printf_LevelDefault(t *testing.T) {
 	}.Run(t)
 }
 
+func Test_LogrusBasedLogger_Warn_LevelDefault(t *testing.T) {
+	TestCase_Logger{
+		Calls: TestLoggerCalls{
+			{Method: "Warn", Args: []interface{}{"log message"}},
+		},
+		Config: &LoggerConfig{},
+		TimeSequence: []time.Time{
+			time.Date(2021, time.Month(2), 1, 3, 4, 3, 8000000, time.UTC),
+			time.Date(2021, time.Month(2), 1, 3, 4, 5, 9000000, time.UTC),
+		},
+		WantOutput: "2021-02-01 03:04:05.009000[+2.001000] [WRN] log message\n",
+	}.Run(t)
+}
+
 func Test_LogrusBasedLogger_Error_LevelDefault(t *testing.T) {
 	TestCase_Logger{
 		Calls: TestLoggerCalls{
@@ -323,3 +342,40 @@ func Test_LogrusBasedLogger_DebugInfo_Sequence(t *testing.T) {
 		WantOutput: "2021-02-01 03:04:05.009000[+2.001000] [DBG] msg1\n2021-02-01 03:04:06.009500[+1.000500] [INF] msg2\n",
 	}.Run(t)
 }
+
+func Test_FmtBasedLogger_WithFields(t *testing.T) {
+	TestCase_Logger{
+		Calls: TestLoggerCalls{
+			{Method: "Infof", Format: "log %s", Args: []interface{}{"message"}, Fields: Fields{"user": "joe", "req": 42}},
+			{Method: "Info", Args: []interface{}{"no fields"}},
+		},
+		Config: &LoggerConfig{},
+		TimeSequence: []time.Time{
+			time.Date(2021, time.Month(2), 1, 3, 4, 3, 8000000, time.UTC),
+			time.Date(2021, time.Month(2), 1, 3, 4, 5, 9000000, time.UTC),
+			time.Date(2021, time.Month(2), 1, 3, 4, 6, 9000000, time.UTC),
+		},
+		WantOutput: "2021-02-01 03:04:05.009000[+2.001000] [INF] log message req=42 user=joe\n" +
+			"2021-02-01 03:04:06.009000[+1.000000] [INF] no fields\n",
+	}.Run(t)
+}
+
+func Test_FmtBasedLogger_WithField_Child(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{ShowNoTime: true, Level: "warn"})
+	assert.NoError(t, err)
+
+	child := logger.WithField("a", 1).WithFields(Fields{"b": "two", "a": "one"})
+
+	var out strings.Builder
+	logger.SetOutput(&out)
+
+	child.Info("filtered by parent level")
+	child.Warn("from child")
+	logger.Warn("from parent")
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.True(t, strings.HasSuffix(lines[0], "[WRN] from child a=one b=two"), lines[0])
+		assert.True(t, strings.HasSuffix(lines[1], "[WRN] from parent"), lines[1])
+	}
+}
@@ -0,0 +1,77 @@
+package justlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// LevelController is a logger whose level may be changed at runtime.
+// FmtBasedLogger and LogrusBasedLogger implement it.
+type LevelController interface {
+	GetLevel() Level
+	SetLevel(level Level)
+}
+
+type levelPayload struct {
+	Level string `json:"level,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// maxLevelRequestBody limits what is read from PUT/POST requests.
+const maxLevelRequestBody = 1024
+
+// NewLevelHTTPHandler returns handler reporting the level of logger on GET
+// and changing it on PUT or POST. New level is taken from JSON body
+// {"level":"debug"}, plain text body or "level" query parameter. Responses
+// are {"level":"..."} or {"error":"..."} JSON objects.
+func NewLevelHTTPHandler(logger LevelController) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPut, http.MethodPost:
+			level, err := levelFromRequest(r)
+			if err != nil {
+				writeLevelResponse(w, http.StatusBadRequest, levelPayload{Error: err.Error()})
+				return
+			}
+			logger.SetLevel(level)
+		default:
+			w.Header().Set("Allow", "GET, HEAD, PUT, POST")
+			writeLevelResponse(w, http.StatusMethodNotAllowed, levelPayload{Error: "method not allowed"})
+			return
+		}
+		writeLevelResponse(w, http.StatusOK, levelPayload{Level: logger.GetLevel().String()})
+	})
+}
+
+func levelFromRequest(r *http.Request) (Level, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxLevelRequestBody))
+	if err != nil {
+		return LogLevelInvalid, fmt.Errorf("read request: %w", err)
+	}
+
+	strLevel := strings.TrimSpace(string(body))
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var payload levelPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return LogLevelInvalid, fmt.Errorf("decode request: %w", err)
+		}
+		strLevel = payload.Level
+	}
+	if strLevel == "" {
+		strLevel = r.URL.Query().Get("level")
+	}
+	if strLevel == "" {
+		return LogLevelInvalid, fmt.Errorf("no level in request")
+	}
+	return ParseLogLevel(strLevel)
+}
+
+func writeLevelResponse(w http.ResponseWriter, status int, payload levelPayload) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,145 @@
+package justlog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type TestCase_LevelHTTPHandler struct {
+	Method      string
+	Target      string
+	ContentType string
+	Body        string
+	WantStatus  int
+	WantBody    string
+	WantLevel   Level
+}
+
+func (tc TestCase_LevelHTTPHandler) Run(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{Level: "info"})
+	assert.NoError(t, err)
+
+	target := tc.Target
+	if target == "" {
+		target = "/loglevel"
+	}
+	req := httptest.NewRequest(tc.Method, target, strings.NewReader(tc.Body))
+	if tc.ContentType != "" {
+		req.Header.Set("Content-Type", tc.ContentType)
+	}
+	rec := httptest.NewRecorder()
+	NewLevelHTTPHandler(logger).ServeHTTP(rec, req)
+
+	assert.Equal(t, tc.WantStatus, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, tc.WantBody, rec.Body.String())
+	assert.Equal(t, tc.WantLevel, logger.GetLevel())
+}
+
+func Test_LevelHTTPHandler_Get(t *testing.T) {
+	TestCase_LevelHTTPHandler{
+		Method:     http.MethodGet,
+		WantStatus: http.StatusOK,
+		WantBody:   `{"level":"info"}` + "\n",
+		WantLevel:  LogLevelInfo,
+	}.Run(t)
+}
+
+func Test_LevelHTTPHandler_PutJSON(t *testing.T) {
+	TestCase_LevelHTTPHandler{
+		Method:      http.MethodPut,
+		ContentType: "application/json",
+		Body:        `{"level":"debug"}`,
+		WantStatus:  http.StatusOK,
+		WantBody:    `{"level":"debug"}` + "\n",
+		WantLevel:   LogLevelDebug,
+	}.Run(t)
+}
+
+func Test_LevelHTTPHandler_PostText(t *testing.T) {
+	TestCase_LevelHTTPHandler{
+		Method:     http.MethodPost,
+		Body:       "trace\n",
+		WantStatus: http.StatusOK,
+		WantBody:   `{"level":"trace"}` + "\n",
+		WantLevel:  LogLevelTrace,
+	}.Run(t)
+}
+
+func Test_LevelHTTPHandler_PostQuery(t *testing.T) {
+	TestCase_LevelHTTPHandler{
+		Method:     http.MethodPost,
+		Target:     "/loglevel?level=error",
+		WantStatus: http.StatusOK,
+		WantBody:   `{"level":"error"}` + "\n",
+		WantLevel:  LogLevelError,
+	}.Run(t)
+}
+
+func Test_LevelHTTPHandler_InvalidLevel(t *testing.T) {
+	TestCase_LevelHTTPHandler{
+		Method:     http.MethodPut,
+		Body:       "loud",
+		WantStatus: http.StatusBadRequest,
+		WantBody:   `{"error":"invalid log level value: \"loud\""}` + "\n",
+		WantLevel:  LogLevelInfo,
+	}.Run(t)
+}
+
+func Test_LevelHTTPHandler_NoLevel(t *testing.T) {
+	TestCase_LevelHTTPHandler{
+		Method:     http.MethodPut,
+		WantStatus: http.StatusBadRequest,
+		WantBody:   `{"error":"no level in request"}` + "\n",
+		WantLevel:  LogLevelInfo,
+	}.Run(t)
+}
+
+func Test_LevelHTTPHandler_MethodNotAllowed(t *testing.T) {
+	TestCase_LevelHTTPHandler{
+		Method:     http.MethodDelete,
+		WantStatus: http.StatusMethodNotAllowed,
+		WantBody:   `{"error":"method not allowed"}` + "\n",
+		WantLevel:  LogLevelInfo,
+	}.Run(t)
+}
+
+func Test_FmtBasedLogger_SetLevel_Concurrent(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{Level: "info"})
+	assert.NoError(t, err)
+	var out strings.Builder
+	logger.SetOutput(&out)
+	child := logger.WithField("k", "v")
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			child.Debug("maybe")
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		logger.SetLevel(Level(LogLevelDebug + Level(i%2)))
+	}
+	wg.Wait()
+
+	logger.SetLevel(LogLevelWarn)
+	assert.Equal(t, LogLevelWarn, logger.GetLevel())
+	assert.Equal(t, LogLevelWarn, child.(*FmtBasedLogger).GetLevel())
+}
+
+func Test_LogrusBasedLogger_SetLevel(t *testing.T) {
+	logger, err := NewLogrusLogger(LoggerConfig{Level: "info"})
+	assert.NoError(t, err)
+	assert.Equal(t, LogLevelInfo, logger.GetLevel())
+
+	logger.SetLevel(LogLevelTrace)
+	assert.Equal(t, LogLevelTrace, logger.GetLevel())
+	assert.True(t, logger.Enabled(LogLevelTrace))
+}
@@ -0,0 +1,149 @@
+package justlog
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// LevelSpec is a parsed level specification like "info,db=debug,http.client=trace":
+// an optional level for the root logger followed by levels of named
+// components, see FmtBasedLogger.Named.
+type LevelSpec struct {
+	Default    Level
+	Components map[string]Level
+}
+
+// ParseLevelSpec parses comma separated list of segments, each being either
+// a level word or component=level. Without a default segment the root level
+// is info, as with ParseLogLevel("").
+func ParseLevelSpec(spec string) (LevelSpec, error) {
+	result := LevelSpec{Default: LogLevelInfo}
+	hasDefault := false
+	for i, segment := range strings.Split(spec, ",") {
+		segment = strings.TrimSpace(segment)
+		if segment == "" {
+			continue
+		}
+		name, levelText := "", segment
+		if eq := strings.IndexByte(segment, '='); eq >= 0 {
+			name, levelText = strings.TrimSpace(segment[:eq]), strings.TrimSpace(segment[eq+1:])
+			if err := checkComponentName(name); err != nil {
+				return LevelSpec{}, fmt.Errorf("level spec segment %d %q: %w", i+1, segment, err)
+			}
+		}
+		if levelText == "" {
+			return LevelSpec{}, fmt.Errorf("level spec segment %d %q: empty level", i+1, segment)
+		}
+		level, err := ParseLogLevel(levelText)
+		if err != nil {
+			return LevelSpec{}, fmt.Errorf("level spec segment %d %q: %w", i+1, segment, err)
+		}
+
+		if name == "" {
+			if hasDefault {
+				return LevelSpec{}, fmt.Errorf("level spec segment %d %q: default level set twice", i+1, segment)
+			}
+			hasDefault = true
+			result.Default = level
+			continue
+		}
+		if _, ok := result.Components[name]; ok {
+			return LevelSpec{}, fmt.Errorf("level spec segment %d %q: level of %q set twice", i+1, segment, name)
+		}
+		if result.Components == nil {
+			result.Components = make(map[string]Level)
+		}
+		result.Components[name] = level
+	}
+	return result, nil
+}
+
+func checkComponentName(name string) error {
+	if name == "" {
+		return fmt.Errorf("empty component name")
+	}
+	for _, part := range strings.Split(name, ".") {
+		if part == "" {
+			return fmt.Errorf("empty part in component name %q", name)
+		}
+	}
+	if strings.ContainsAny(name, " \t\"") {
+		return fmt.Errorf("invalid character in component name %q", name)
+	}
+	return nil
+}
+
+// String returns spec in the form accepted by ParseLevelSpec, components
+// sorted by name.
+func (spec LevelSpec) String() string {
+	names := make([]string, 0, len(spec.Components))
+	for name := range spec.Components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(spec.Default.String())
+	for _, name := range names {
+		b.WriteByte(',')
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(spec.Components[name].String())
+	}
+	return b.String()
+}
+
+// levelSpecFromConfig returns spec from environment variable named by
+// cfg.LevelEnv when it is set and not empty, from cfg.Level otherwise.
+func levelSpecFromConfig(cfg *LoggerConfig) (LevelSpec, error) {
+	if cfg.LevelEnv != "" {
+		if spec, ok := os.LookupEnv(cfg.LevelEnv); ok && spec != "" {
+			parsed, err := ParseLevelSpec(spec)
+			if err != nil {
+				return LevelSpec{}, fmt.Errorf("%s: %w", cfg.LevelEnv, err)
+			}
+			return parsed, nil
+		}
+	}
+	return ParseLevelSpec(cfg.Level)
+}
+
+// ApplyLevelSpec sets level of the root logger and of the named components
+// listed in spec. Components not listed in spec are returned to inheriting
+// their level. Components may be named later, they get their level from spec
+// as soon as Named is called.
+func (logger *FmtBasedLogger) ApplyLevelSpec(spec LevelSpec) {
+	root := logger.root()
+	for name, level := range spec.Components {
+		atomic.StoreUint32(&root.levelNodeFor(name).level, uint32(level))
+	}
+
+	root.namedMu.Lock()
+	for name, node := range root.named {
+		if _, ok := spec.Components[name]; !ok {
+			atomic.StoreUint32(&node.level, 0)
+		}
+	}
+	root.namedMu.Unlock()
+
+	root.SetLevel(spec.Default)
+}
+
+// LevelSpec returns current levels of the root logger and of the components
+// having their own level.
+func (logger *FmtBasedLogger) LevelSpec() LevelSpec {
+	spec := LevelSpec{Default: logger.root().GetLevel()}
+	for _, named := range logger.NamedLevels() {
+		if named.Inherited {
+			continue
+		}
+		if spec.Components == nil {
+			spec.Components = make(map[string]Level)
+		}
+		spec.Components[named.Name] = named.Level
+	}
+	return spec
+}
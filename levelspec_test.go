@@ -0,0 +1,145 @@
+package justlog
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type TestCase_ParseLevelSpec struct {
+	Spec      string
+	Want      LevelSpec
+	WantError string
+}
+
+func (tc TestCase_ParseLevelSpec) Run(t *testing.T) {
+	t.Helper()
+	spec, err := ParseLevelSpec(tc.Spec)
+	if tc.WantError != "" {
+		if assert.Error(t, err) {
+			assert.Equal(t, tc.WantError, err.Error())
+		}
+		return
+	}
+	assert.NoError(t, err)
+	assert.Equal(t, tc.Want, spec)
+}
+
+func Test_ParseLevelSpec_Empty(t *testing.T) {
+	TestCase_ParseLevelSpec{Spec: "", Want: LevelSpec{Default: LogLevelInfo}}.Run(t)
+}
+
+func Test_ParseLevelSpec_SingleLevel(t *testing.T) {
+	TestCase_ParseLevelSpec{Spec: "warn", Want: LevelSpec{Default: LogLevelWarn}}.Run(t)
+}
+
+func Test_ParseLevelSpec_Components(t *testing.T) {
+	TestCase_ParseLevelSpec{
+		Spec: "info, db=debug,http.client = trace,",
+		Want: LevelSpec{
+			Default:    LogLevelInfo,
+			Components: map[string]Level{"db": LogLevelDebug, "http.client": LogLevelTrace},
+		},
+	}.Run(t)
+}
+
+func Test_ParseLevelSpec_ComponentsOnly(t *testing.T) {
+	TestCase_ParseLevelSpec{
+		Spec: "db=error",
+		Want: LevelSpec{Default: LogLevelInfo, Components: map[string]Level{"db": LogLevelError}},
+	}.Run(t)
+}
+
+func Test_ParseLevelSpec_InvalidLevel(t *testing.T) {
+	TestCase_ParseLevelSpec{
+		Spec:      "info,db=verbose",
+		WantError: `level spec segment 2 "db=verbose": invalid log level value: "verbose"`,
+	}.Run(t)
+}
+
+func Test_ParseLevelSpec_EmptyName(t *testing.T) {
+	TestCase_ParseLevelSpec{
+		Spec:      "=debug",
+		WantError: `level spec segment 1 "=debug": empty component name`,
+	}.Run(t)
+}
+
+func Test_ParseLevelSpec_EmptyNamePart(t *testing.T) {
+	TestCase_ParseLevelSpec{
+		Spec:      "info,db..pool=debug",
+		WantError: `level spec segment 2 "db..pool=debug": empty part in component name "db..pool"`,
+	}.Run(t)
+}
+
+func Test_ParseLevelSpec_EmptyLevel(t *testing.T) {
+	TestCase_ParseLevelSpec{
+		Spec:      "db=",
+		WantError: `level spec segment 1 "db=": empty level`,
+	}.Run(t)
+}
+
+func Test_ParseLevelSpec_DefaultTwice(t *testing.T) {
+	TestCase_ParseLevelSpec{
+		Spec:      "info,db=debug,warn",
+		WantError: `level spec segment 3 "warn": default level set twice`,
+	}.Run(t)
+}
+
+func Test_ParseLevelSpec_ComponentTwice(t *testing.T) {
+	TestCase_ParseLevelSpec{
+		Spec:      "db=debug,db=warn",
+		WantError: `level spec segment 2 "db=warn": level of "db" set twice`,
+	}.Run(t)
+}
+
+func Test_LevelSpec_String(t *testing.T) {
+	spec, err := ParseLevelSpec("http.client=trace,warn,db=debug")
+	assert.NoError(t, err)
+	assert.Equal(t, "warn,db=debug,http.client=trace", spec.String())
+}
+
+func Test_FmtBasedLogger_ApplyLevelSpec(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{ShowNoTime: true, Level: "warn,db=debug"})
+	assert.NoError(t, err)
+
+	db := logger.Named("db")
+	pool := db.Named("pool")
+	http := logger.Named("http")
+
+	assert.Equal(t, LogLevelWarn, logger.GetLevel())
+	assert.Equal(t, LogLevelDebug, pool.GetLevel())
+	assert.Equal(t, LogLevelWarn, http.GetLevel())
+
+	spec, err := ParseLevelSpec("error,db.pool=trace,http=info")
+	assert.NoError(t, err)
+	logger.ApplyLevelSpec(spec)
+
+	assert.Equal(t, LogLevelError, logger.GetLevel())
+	assert.Equal(t, LogLevelError, db.GetLevel())
+	assert.Equal(t, LogLevelTrace, pool.GetLevel())
+	assert.Equal(t, LogLevelInfo, http.GetLevel())
+	assert.Equal(t, spec, logger.LevelSpec())
+}
+
+func Test_NewFmtBasedLogger_LevelEnv(t *testing.T) {
+	const env = "JUSTLOG_TEST_LEVEL"
+	defer os.Unsetenv(env)
+
+	os.Setenv(env, "error,db=debug")
+	logger, err := NewFmtBasedLogger(LoggerConfig{Level: "info", LevelEnv: env})
+	assert.NoError(t, err)
+	assert.Equal(t, "error,db=debug", logger.LevelSpec().String())
+
+	os.Setenv(env, "")
+	logger, err = NewFmtBasedLogger(LoggerConfig{Level: "info", LevelEnv: env})
+	assert.NoError(t, err)
+	assert.Equal(t, "info", logger.LevelSpec().String())
+
+	os.Setenv(env, "db=loud")
+	_, err = NewFmtBasedLogger(LoggerConfig{Level: "info", LevelEnv: env})
+	if assert.Error(t, err) {
+		assert.True(t, strings.Contains(err.Error(), env+`: level spec segment 1 "db=loud"`), err.Error())
+	}
+}
@@ -0,0 +1,114 @@
+package justlog
+
+import (
+	"unicode/utf8"
+)
+
+type LogfmtEncoder struct {
+	TimeFormat  string
+	ShowNoTime  bool
+	ShowNoDelta bool
+}
+
+func NewLogfmtEncoder(cfg *LoggerConfig, deltaMode DeltaMode) *LogfmtEncoder {
+	enc := &LogfmtEncoder{TimeFormat: DefaultTimeFormat, ShowNoDelta: deltaMode == DeltaNone}
+	if cfg == nil {
+		return enc
+	}
+	if cfg.TimeFormat != "" {
+		enc.TimeFormat = cfg.TimeFormat
+	}
+	enc.ShowNoTime = cfg.ShowNoTime
+	return enc
+}
+
+func (enc *LogfmtEncoder) Encode(buf []byte, rec *Record) []byte {
+	if !enc.ShowNoTime {
+		buf = append(buf, "ts="...)
+		mark := len(buf)
+		buf = appendTimeFormat(buf, rec.Time, enc.TimeFormat)
+		buf = quoteLogfmtTail(buf, mark)
+		buf = append(buf, ' ')
+	}
+	if !enc.ShowNoDelta {
+		buf = append(buf, "delta="...)
+		buf = appendSeconds(buf, rec.Delta)
+		buf = append(buf, ' ')
+		if rec.Flow != nil {
+			buf = append(buf, "elapsed="...)
+			buf = appendSeconds(buf, rec.Elapsed)
+			buf = append(buf, ' ')
+		}
+	}
+	buf = append(buf, "level="...)
+	buf = append(buf, rec.Level.String()...)
+	if rec.Name != "" {
+		buf = append(buf, " logger="...)
+		mark := len(buf)
+		buf = append(buf, rec.Name...)
+		buf = quoteLogfmtTail(buf, mark)
+	}
+	if rec.Caller != "" {
+		buf = append(buf, " caller="...)
+		mark := len(buf)
+		buf = append(buf, rec.Caller...)
+		buf = quoteLogfmtTail(buf, mark)
+	}
+	buf = append(buf, " msg="...)
+	mark := len(buf)
+	buf = append(buf, rec.Message...)
+	buf = quoteLogfmtTail(buf, mark)
+	buf = appendFieldsLogfmt(buf, rec.Fields)
+	buf = append(buf, '\n')
+	return buf
+}
+
+func logfmtNeedsQuote(s []byte) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for _, c := range s {
+		if c <= ' ' || c == '=' || c == '"' || c == '\\' || c == 0x7f {
+			return true
+		}
+	}
+	return !utf8.Valid(s)
+}
+
+// quoteLogfmtTail quotes buf[mark:] in place when the value written there
+// can not be parsed back as a bare logfmt value.
+func quoteLogfmtTail(buf []byte, mark int) []byte {
+	if !logfmtNeedsQuote(buf[mark:]) {
+		return buf
+	}
+	value := append([]byte(nil), buf[mark:]...)
+	buf = append(buf[:mark], '"')
+	buf = appendJSONEscaped(buf, value)
+	return append(buf, '"')
+}
+
+func appendLogfmtKey(buf []byte, key string) []byte {
+	if key == "" {
+		return append(buf, '_')
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c <= ' ' || c == '=' || c == '"' || c == 0x7f {
+			c = '_'
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
+
+func appendFieldsLogfmt(buf []byte, fields []Field) []byte {
+	for i := range fields {
+		buf = append(buf, ' ')
+		buf = appendLogfmtKey(buf, fields[i].Key)
+		buf = append(buf, '=')
+		mark := len(buf)
+		buf = appendField(buf, &fields[i])
+		buf = quoteLogfmtTail(buf, mark)
+	}
+	return buf
+}
@@ -0,0 +1,57 @@
+package justlog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FmtBasedLogger_Infof_EncodingLogfmt(t *testing.T) {
+	TestCase_Logger{
+		Calls: TestLoggerCalls{
+			{Method: "Infof", Format: "log %s", Args: []interface{}{"message"}, Fields: Fields{"user": "joe", "req": 42}},
+		},
+		Config: &LoggerConfig{
+			Encoding: EncodingLogfmt,
+		},
+		TimeSequence: []time.Time{
+			time.Date(2021, time.Month(2), 1, 3, 4, 3, 8000000, time.UTC),
+			time.Date(2021, time.Month(2), 1, 3, 4, 5, 9000000, time.UTC),
+		},
+		WantOutput: `ts="2021-02-01 03:04:05.009000" delta=2.001000 level=info msg="log message" req=42 user=joe` + "\n",
+	}.Run(t)
+}
+
+func Test_FmtBasedLogger_Error_EncodingLogfmt_CustomTimeFormat(t *testing.T) {
+	TestCase_Logger{
+		Calls: TestLoggerCalls{
+			{Method: "Error", Args: []interface{}{"failed"}, Fields: Fields{"err": "say \"no\"\nagain", "empty": "", "k=v": "x"}},
+		},
+		Config: &LoggerConfig{
+			Encoding:   EncodingLogfmt,
+			TimeFormat: "2006-01-02T15:04:05Z07:00",
+		},
+		TimeSequence: []time.Time{
+			time.Date(2021, time.Month(2), 1, 3, 4, 3, 8000000, time.UTC),
+			time.Date(2021, time.Month(2), 1, 3, 4, 5, 9000000, time.UTC),
+		},
+		WantOutput: `ts=2021-02-01T03:04:05Z delta=2.001000 level=error msg=failed empty="" err="say \"no\"\nagain" k_v=x` + "\n",
+	}.Run(t)
+}
+
+func Test_quoteLogfmtTail(t *testing.T) {
+	for in, want := range map[string]string{
+		"bare":       "key=bare",
+		"":           `key=""`,
+		"two words":  `key="two words"`,
+		"a=b":        `key="a=b"`,
+		"back\\sl":   `key="back\\sl"`,
+		"tab\there":  `key="tab\there"`,
+		"юникод":     "key=юникод",
+		"bad\xffutf": `key="bad�utf"`,
+	} {
+		buf := append([]byte("key="), in...)
+		assert.Equal(t, want, string(quoteLogfmtTail(buf, len("key="))), in)
+	}
+}
@@ -69,6 +69,18 @@ func (_m *MockLogger) Infof(format string, args ...interface{}) {
 	_m.Called(_ca...)
 }
 
+// LogFields provides a mock function with given fields: level, msg, fields
+func (_m *MockLogger) LogFields(level Level, msg string, fields ...Field) {
+	_va := make([]interface{}, len(fields))
+	for _i := range fields {
+		_va[_i] = fields[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, level, msg)
+	_ca = append(_ca, _va...)
+	_m.Called(_ca...)
+}
+
 // Print provides a mock function with given fields: args
 func (_m *MockLogger) Print(args ...interface{}) {
 	var _ca []interface{}
@@ -113,3 +125,35 @@ func (_m *MockLogger) Warnf(format string, args ...interface{}) {
 	_ca = append(_ca, args...)
 	_m.Called(_ca...)
 }
+
+// WithField provides a mock function with given fields: key, value
+func (_m *MockLogger) WithField(key string, value interface{}) Logger {
+	ret := _m.Called(key, value)
+
+	var r0 Logger
+	if rf, ok := ret.Get(0).(func(string, interface{}) Logger); ok {
+		r0 = rf(key, value)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Logger)
+		}
+	}
+
+	return r0
+}
+
+// WithFields provides a mock function with given fields: fields
+func (_m *MockLogger) WithFields(fields Fields) Logger {
+	ret := _m.Called(fields)
+
+	var r0 Logger
+	if rf, ok := ret.Get(0).(func(Fields) Logger); ok {
+		r0 = rf(fields)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Logger)
+		}
+	}
+
+	return r0
+}
@@ -1,9 +1,10 @@
 package justlog
 
 import (
-	"bytes"
 	"fmt"
 	"io"
+	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -15,17 +16,17 @@ func NewLogrusLogger(cfg LoggerConfig) (*LogrusBasedLogger, error) {
 		Log: logrus.New(),
 	}
 
-	logLevelText := cfg.Level
-	if logLevelText == "" {
-		logLevelText = "info"
-	}
-	logLevel, err := logrus.ParseLevel(logLevelText)
+	// logrus has no named loggers, component levels of the spec are unused
+	levelSpec, err := levelSpecFromConfig(&cfg)
 	if err != nil {
-		return nil, fmt.Errorf("parse loglevel %q: %w", logLevelText, err)
+		return nil, fmt.Errorf("ParseLevelSpec error: %w", err)
 	}
-	logger.Log.SetLevel(logLevel)
+	logger.Log.SetLevel(levelToLogrus(levelSpec.Default))
 
-	fmtr := NewLogrusFormatter(&cfg)
+	fmtr, err := NewLogrusFormatter(&cfg)
+	if err != nil {
+		return nil, err
+	}
 	logger.Log.SetFormatter(fmtr)
 
 	logger.LogEntry = logrus.NewEntry(logger.Log)
@@ -74,6 +75,22 @@ func (logger *LogrusBasedLogger) Printf(format string, args ...interface{}) {
 	logger.LogEntry.Infof(format, args...)
 }
 
+func (logger *LogrusBasedLogger) LogFields(level Level, msg string, fields ...Field) {
+	lvl := levelToLogrus(level)
+	if !logger.Log.IsLevelEnabled(lvl) {
+		return
+	}
+	entry := logger.LogEntry
+	if len(fields) > 0 {
+		data := make(logrus.Fields, len(fields))
+		for _, field := range fields {
+			data[field.Key] = field.Interface()
+		}
+		entry = entry.WithFields(data)
+	}
+	entry.Log(lvl, msg)
+}
+
 func (logger *LogrusBasedLogger) Warn(args ...interface{}) {
 	logger.LogEntry.Warn(args...)
 }
@@ -98,70 +115,129 @@ func (logger *LogrusBasedLogger) Fatalf(format string, args ...interface{}) {
 	logger.LogEntry.Fatalf(format, args...)
 }
 
-type LogrusFormatter struct {
-	PrevTime   time.Time
-	TimeFormat string
-	ShowNoTime bool
+func (logger *LogrusBasedLogger) WithField(key string, value interface{}) Logger {
+	return &LogrusBasedLogger{
+		Log:      logger.Log,
+		LogEntry: logger.LogEntry.WithField(key, value),
+	}
 }
 
-func NewLogrusFormatter(cfg *LoggerConfig) *LogrusFormatter {
-	f := &LogrusFormatter{
-		TimeFormat: "2006-01-02 15:04:05.000000",
-		PrevTime:   time.Now(),
+func (logger *LogrusBasedLogger) WithFields(fields Fields) Logger {
+	return &LogrusBasedLogger{
+		Log:      logger.Log,
+		LogEntry: logger.LogEntry.WithFields(logrus.Fields(fields)),
 	}
+}
 
-	if cfg == nil {
-		return f
-	}
+func (logger *LogrusBasedLogger) SetLevel(level Level) {
+	logger.Log.SetLevel(levelToLogrus(level))
+}
 
-	if cfg.TimeFormat != "" {
-		f.TimeFormat = cfg.TimeFormat
-	}
-	f.ShowNoTime = cfg.ShowNoTime
+func (logger *LogrusBasedLogger) GetLevel() Level {
+	return levelFromLogrus(logger.Log.GetLevel())
+}
 
-	return f
+func (logger *LogrusBasedLogger) Enabled(level Level) bool {
+	return logger.Log.IsLevelEnabled(levelToLogrus(level))
 }
 
-func (f *LogrusFormatter) Format(ent *logrus.Entry) ([]byte, error) {
-	buf := ent.Buffer
-	if buf == nil {
-		buf = &bytes.Buffer{}
+// Handle logs rec through LogEntry, adding rec fields to the entry data.
+func (logger *LogrusBasedLogger) Handle(rec *Record) error {
+	entry := logger.LogEntry.WithTime(rec.Time)
+	if len(rec.Fields) > 0 {
+		data := make(logrus.Fields, len(rec.Fields))
+		for _, field := range rec.Fields {
+			data[field.Key] = field.Interface()
+		}
+		entry = entry.WithFields(data)
 	}
+	entry.Log(levelToLogrus(rec.Level), string(rec.Message))
+	return nil
+}
 
-	sinceLastLog := ent.Time.Sub(f.PrevTime) // FIXME: store time with atomic
-	f.PrevTime = ent.Time
+type LogrusFormatter struct {
+	Encoder   Encoder
+	DeltaMode DeltaMode
+	delta     *DeltaTracker
+}
 
-	if !f.ShowNoTime {
-		buf.WriteString(ent.Time.Format(f.TimeFormat))
+func NewLogrusFormatter(cfg *LoggerConfig) (*LogrusFormatter, error) {
+	f := &LogrusFormatter{
+		delta: NewDeltaTracker(time.Now()),
+	}
+	if cfg != nil {
+		deltaMode, err := ParseDeltaMode(cfg.DeltaMode)
+		if err != nil {
+			return nil, fmt.Errorf("ParseDeltaMode error: %w", err)
+		}
+		f.DeltaMode = deltaMode
 	}
 
-	buf.WriteString("[+")
-	buf.WriteString(f.durationSecondsString(sinceLastLog))
-	buf.WriteRune(']')
-	buf.WriteRune(' ')
-	buf.Write(logLevelString(ent.Level))
-	buf.WriteRune(' ')
-	buf.WriteString(ent.Message)
-	buf.WriteRune('\n')
-	return buf.Bytes(), nil
+	encoder, err := NewEncoder(cfg, f.DeltaMode)
+	if err != nil {
+		return nil, fmt.Errorf("NewEncoder error: %w", err)
+	}
+	f.Encoder = encoder
+
+	return f, nil
 }
 
-func (f *LogrusFormatter) durationSecondsString(d time.Duration) string {
-	return fmt.Sprintf("%.6f", d.Seconds())
+// SetPrevTime sets time the delta of the next entry is counted from.
+func (f *LogrusFormatter) SetPrevTime(prev time.Time) {
+	f.delta.Set(prev)
 }
 
-func logLevelString(lvl logrus.Level) []byte {
+func (f *LogrusFormatter) Format(ent *logrus.Entry) ([]byte, error) {
+	rec := Record{
+		Time:    ent.Time,
+		Level:   levelFromLogrus(ent.Level),
+		Message: []byte(ent.Message),
+		Fields:  mergeFields(nil, Fields(ent.Data)),
+		Flow:    FlowFromContext(ent.Context),
+	}
+	setRecordDelta(&rec, f.DeltaMode, f.delta)
+	if ent.HasCaller() {
+		rec.Caller = filepath.Base(ent.Caller.File) + ":" + strconv.Itoa(ent.Caller.Line)
+	}
+
+	var buf []byte
+	if ent.Buffer != nil {
+		buf = ent.Buffer.Bytes()[:0]
+	}
+	buf = f.Encoder.Encode(buf, &rec)
+	return buf, nil
+}
+
+func levelFromLogrus(lvl logrus.Level) Level {
 	switch lvl {
 	case logrus.TraceLevel:
-		return stringLevelTrace
+		return LogLevelTrace
 	case logrus.DebugLevel:
-		return stringLevelDebug
+		return LogLevelDebug
 	case logrus.InfoLevel:
-		return stringLevelInfo
+		return LogLevelInfo
+	case logrus.WarnLevel:
+		return LogLevelWarn
 	case logrus.ErrorLevel:
-		return stringLevelError
+		return LogLevelError
 	case logrus.FatalLevel:
-		return stringLevelFatal
+		return LogLevelFatal
+	}
+	return LogLevelInvalid
+}
+
+func levelToLogrus(lvl Level) logrus.Level {
+	switch lvl {
+	case LogLevelTrace:
+		return logrus.TraceLevel
+	case LogLevelDebug:
+		return logrus.DebugLevel
+	case LogLevelInfo:
+		return logrus.InfoLevel
+	case LogLevelWarn:
+		return logrus.WarnLevel
+	case LogLevelError:
+		return logrus.ErrorLevel
 	}
-	return stringLevelWTF
+	return logrus.FatalLevel
 }
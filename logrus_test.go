@@ -24,10 +24,13 @@ type TestCase_LogrusFormatter_Format struct {
 }
 
 func (tc TestCase_LogrusFormatter_Format) Run(t *testing.T) {
-	formatter := NewLogrusFormatter(tc.Config)
+	formatter, err := NewLogrusFormatter(tc.Config)
+	if !assert.NoError(t, err) {
+		return
+	}
 
 	if !tc.PrevTime.IsZero() {
-		formatter.PrevTime = tc.PrevTime
+		formatter.SetPrevTime(tc.PrevTime)
 	}
 
 	for _, call := range tc.Call {
@@ -107,3 +110,48 @@ func Test_LogrusFormatter_Format_OmitTime(t *testing.T) {
 		},
 	}.Run(t)
 }
+
+func Test_LogrusFormatter_Format_Fields(t *testing.T) {
+	TestCase_LogrusFormatter_Format{
+		Config: &LoggerConfig{
+			ShowNoTime: true,
+		},
+		PrevTime: time.Date(2020, time.Month(5), 6, 1, 2, 3, 7890000, time.UTC),
+		Call: []logrusFormatCall{
+			{
+				Entry: &logrus.Entry{
+					Time:    time.Date(2020, time.Month(5), 6, 1, 2, 4, 7890000, time.UTC),
+					Message: "MSG!",
+					Level:   logrus.InfoLevel,
+					Data:    logrus.Fields{"user": "joe", "id": 7},
+				},
+				WantBytes: "[+1.000000] [INF] MSG! id=7 user=joe\n",
+			},
+		},
+	}.Run(t)
+}
+
+func Test_NewLogrusLogger_LevelSpec(t *testing.T) {
+	logger, err := NewLogrusLogger(LoggerConfig{Level: "warn,db=debug"})
+	assert.NoError(t, err)
+	assert.Equal(t, LogLevelWarn, logger.GetLevel())
+
+	logger, err = NewLogrusLogger(LoggerConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, LogLevelInfo, logger.GetLevel())
+
+	_, err = NewLogrusLogger(LoggerConfig{Level: "info,db=loud"})
+	assert.Error(t, err)
+}
+
+func Test_NewLogrusFormatter_InvalidConfig(t *testing.T) {
+	for _, cfg := range []LoggerConfig{
+		{DeltaMode: "sometimes"},
+		{Encoding: "yaml"},
+	} {
+		_, err := NewLogrusFormatter(&cfg)
+		assert.Error(t, err, "%+v", cfg)
+		_, err = NewLogrusLogger(cfg)
+		assert.Error(t, err, "%+v", cfg)
+	}
+}
@@ -0,0 +1,102 @@
+package justlog
+
+import (
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// levelNode holds level of a named logger. Zero level means it is inherited
+// from the parent node. Root node has no parent and always a level set.
+type levelNode struct {
+	name   string
+	parent *levelNode
+	level  uint32
+}
+
+func (node *levelNode) effective() Level {
+	for ; node != nil; node = node.parent {
+		if level := atomic.LoadUint32(&node.level); level != 0 {
+			return Level(level)
+		}
+	}
+	return LogLevelInvalid
+}
+
+// Named returns child logger for a component. Its lines are tagged with the
+// name, dot-separated from names of named parents: Named("db").Named("pool")
+// is "db.pool", same as Named("db.pool"). The child inherits level from the
+// parent component until SetLevel is called on it, SetLevel(LogLevelInvalid)
+// returns it to inheriting. Loggers with the same name share the level.
+func (logger *FmtBasedLogger) Named(name string) *FmtBasedLogger {
+	if name == "" {
+		return logger
+	}
+	if logger.name != "" {
+		name = logger.name + "." + name
+	}
+	child := logger.child()
+	child.name = name
+	child.node = logger.root().levelNodeFor(name)
+	return child
+}
+
+func (logger *FmtBasedLogger) Name() string {
+	return logger.name
+}
+
+// levelNodeFor returns registry node of name, creating it and nodes for
+// its dotted prefixes if needed. Must be called on the root.
+func (logger *FmtBasedLogger) levelNodeFor(name string) *levelNode {
+	logger.namedMu.Lock()
+	defer logger.namedMu.Unlock()
+	if logger.named == nil {
+		logger.named = make(map[string]*levelNode)
+	}
+
+	node := logger.node
+	for end := 0; end < len(name); {
+		next := strings.IndexByte(name[end+1:], '.')
+		if next < 0 {
+			end = len(name)
+		} else {
+			end += 1 + next
+		}
+		prefix := name[:end]
+		child, ok := logger.named[prefix]
+		if !ok {
+			child = &levelNode{name: prefix, parent: node}
+			logger.named[prefix] = child
+		}
+		node = child
+	}
+	return node
+}
+
+type NamedLevel struct {
+	Name  string
+	Level Level
+	// Inherited is true when the level comes from a parent component.
+	Inherited bool
+}
+
+// NamedLevels lists all components created by Named with their effective
+// levels, sorted by name.
+func (logger *FmtBasedLogger) NamedLevels() []NamedLevel {
+	root := logger.root()
+	root.namedMu.Lock()
+	defer root.namedMu.Unlock()
+
+	levels := make([]NamedLevel, 0, len(root.named))
+	for name, node := range root.named {
+		levels = append(levels, NamedLevel{
+			Name:      name,
+			Level:     node.effective(),
+			Inherited: atomic.LoadUint32(&node.level) == 0,
+		})
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		return levels[i].Name < levels[j].Name
+	})
+	return levels
+}
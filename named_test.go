@@ -0,0 +1,64 @@
+package justlog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FmtBasedLogger_Named_Levels(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{ShowNoTime: true, Level: "info"})
+	assert.NoError(t, err)
+
+	db := logger.Named("db")
+	pool := db.Named("pool")
+	http := logger.Named("http").WithField("port", 80)
+
+	var out strings.Builder
+	logger.SetOutput(&out)
+
+	db.SetLevel(LogLevelDebug)
+	pool.Debug("inherited from db")
+	http.Debug("filtered by root level")
+	http.Info("started")
+	pool.SetLevel(LogLevelWarn)
+	pool.Info("filtered by own level")
+	pool.SetLevel(LogLevelInvalid)
+	pool.Debug("inherits again")
+	logger.Named("db.pool").Warn("same component")
+
+	assert.Equal(t, "db.pool", pool.Name())
+	want := []string{
+		"[DBG] [db.pool] inherited from db",
+		"[INF] [http] started port=80",
+		"[DBG] [db.pool] inherits again",
+		"[WRN] [db.pool] same component",
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if assert.Len(t, lines, len(want)) {
+		for i := range want {
+			assert.True(t, strings.HasSuffix(lines[i], want[i]), lines[i])
+		}
+	}
+
+	assert.Equal(t, []NamedLevel{
+		{Name: "db", Level: LogLevelDebug},
+		{Name: "db.pool", Level: LogLevelDebug, Inherited: true},
+		{Name: "http", Level: LogLevelInfo, Inherited: true},
+	}, logger.NamedLevels())
+}
+
+func Test_FmtBasedLogger_Named_Encodings(t *testing.T) {
+	for encoding, want := range map[string]string{
+		EncodingJSON:   `"level":"info","logger":"db.pool","msg":"ready"}` + "\n",
+		EncodingLogfmt: " level=info logger=db.pool msg=ready\n",
+	} {
+		logger, err := NewFmtBasedLogger(LoggerConfig{ShowNoTime: true, Encoding: encoding})
+		assert.NoError(t, err)
+		var out strings.Builder
+		logger.SetOutput(&out)
+		logger.Named("db.pool").Info("ready")
+		assert.True(t, strings.HasSuffix(out.String(), want), out.String())
+	}
+}
@@ -0,0 +1,8 @@
+//go:build race
+// +build race
+
+package justlog
+
+// raceEnabled skips allocation checks, sync.Pool drops items at random
+// under the race detector.
+const raceEnabled = true
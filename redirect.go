@@ -0,0 +1,197 @@
+package justlog
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// levelWriter is implemented by FmtBasedLogger. It lets redirected fatal
+// lines be written without exiting, the redirected logger exits by itself.
+type levelWriter interface {
+	WriteMessage(Level Level, Time time.Time, args ...interface{})
+}
+
+func logAtLevel(logger Logger, level Level, msg string) {
+	if level == LogLevelFatal {
+		// The redirected logger exits after a fatal line, queued lines
+		// would be lost.
+		if flusher, ok := logger.(Flusher); ok {
+			defer flusher.Flush()
+		}
+	}
+	if writer, ok := logger.(levelWriter); ok {
+		writer.WriteMessage(level, time.Now(), msg)
+		return
+	}
+	switch level {
+	case LogLevelTrace:
+		logger.Trace(msg)
+	case LogLevelDebug:
+		logger.Debug(msg)
+	case LogLevelWarn:
+		logger.Warn(msg)
+	case LogLevelError, LogLevelFatal:
+		logger.Error(msg)
+	default:
+		logger.Info(msg)
+	}
+}
+
+var levelPrefixes = map[string]Level{
+	"trace":    LogLevelTrace,
+	"debug":    LogLevelDebug,
+	"info":     LogLevelInfo,
+	"notice":   LogLevelInfo,
+	"warn":     LogLevelWarn,
+	"warning":  LogLevelWarn,
+	"err":      LogLevelError,
+	"error":    LogLevelError,
+	"fatal":    LogLevelFatal,
+	"panic":    LogLevelFatal,
+	"critical": LogLevelFatal,
+}
+
+// parseLevelPrefix recognises level words at the start of line written as
+// "[ERROR] msg", "ERROR: msg" or "error msg" (the latter only in upper case).
+func parseLevelPrefix(line string) (Level, string, bool) {
+	var word, rest string
+	switch {
+	case strings.HasPrefix(line, "["):
+		end := strings.IndexByte(line, ']')
+		if end < 0 {
+			return LogLevelInvalid, line, false
+		}
+		word, rest = line[1:end], line[end+1:]
+	default:
+		end := strings.IndexAny(line, ": ")
+		if end < 0 {
+			return LogLevelInvalid, line, false
+		}
+		word, rest = line[:end], line[end:]
+		if line[end] == ':' {
+			rest = line[end+1:]
+		} else if word != strings.ToUpper(word) {
+			return LogLevelInvalid, line, false
+		}
+	}
+	level, ok := levelPrefixes[strings.ToLower(word)]
+	if !ok {
+		return LogLevelInvalid, line, false
+	}
+	return level, strings.TrimLeft(rest, " "), true
+}
+
+// stripStdLogTime removes date and time written by the log package with
+// Ldate, Ltime and Lmicroseconds flags.
+func stripStdLogTime(line string) string {
+	if len(line) >= 11 && isDigits(line[0:4]) && line[4] == '/' && isDigits(line[5:7]) &&
+		line[7] == '/' && isDigits(line[8:10]) && line[10] == ' ' {
+		line = line[11:]
+	}
+	if len(line) >= 9 && isDigits(line[0:2]) && line[2] == ':' && isDigits(line[3:5]) &&
+		line[5] == ':' && isDigits(line[6:8]) {
+		rest := line[8:]
+		if len(rest) >= 7 && rest[0] == '.' && isDigits(rest[1:7]) {
+			rest = rest[7:]
+		}
+		if strings.HasPrefix(rest, " ") {
+			line = rest[1:]
+		}
+	}
+	return line
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// stdLogWriter receives lines of the log package. Lines without level
+// prefix are written with the default level.
+type stdLogWriter struct {
+	logger Logger
+	level  Level
+}
+
+func (w *stdLogWriter) Write(p []byte) (int, error) {
+	for _, line := range bytes.Split(bytes.TrimRight(p, "\n"), []byte{'\n'}) {
+		msg := stripStdLogTime(string(line))
+		level, rest, ok := parseLevelPrefix(msg)
+		if !ok {
+			level = w.level
+		}
+		logAtLevel(w.logger, level, rest)
+	}
+	return len(p), nil
+}
+
+// RedirectStdLog makes the standard log package write through logger.
+// The returned function restores previous output, flags and prefix of the
+// log package.
+func RedirectStdLog(logger Logger, level Level) (restore func()) {
+	out, flags, prefix := log.Writer(), log.Flags(), log.Prefix()
+	log.SetFlags(0)
+	log.SetPrefix("")
+	log.SetOutput(&stdLogWriter{logger: logger, level: level})
+	return func() {
+		log.SetOutput(out)
+		log.SetFlags(flags)
+		log.SetPrefix(prefix)
+	}
+}
+
+// logrusRedirectHook passes entries of the logrus global logger to logger.
+type logrusRedirectHook struct {
+	logger Logger
+}
+
+func (hook *logrusRedirectHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (hook *logrusRedirectHook) Fire(entry *logrus.Entry) error {
+	level := levelFromLogrus(entry.Level)
+	if entry.Level == logrus.PanicLevel {
+		level = LogLevelFatal
+	}
+	logger := hook.logger
+	if len(entry.Data) > 0 {
+		logger = logger.WithFields(Fields(entry.Data))
+	}
+	logAtLevel(logger, level, entry.Message)
+	return nil
+}
+
+type discardFormatter struct{}
+
+func (discardFormatter) Format(*logrus.Entry) ([]byte, error) {
+	return nil, nil
+}
+
+// RedirectLogrusGlobal makes the logrus standard logger pass all entries to
+// logger, which filters them by its own level. The returned function
+// restores output, formatter, level and hooks of the logrus standard logger.
+func RedirectLogrusGlobal(logger Logger) (restore func()) {
+	std := logrus.StandardLogger()
+	out, formatter, level := std.Out, std.Formatter, std.GetLevel()
+	hooks := std.ReplaceHooks(logrus.LevelHooks{})
+	std.AddHook(&logrusRedirectHook{logger: logger})
+	std.SetFormatter(discardFormatter{})
+	std.SetOutput(io.Discard)
+	std.SetLevel(logrus.TraceLevel)
+	return func() {
+		std.SetLevel(level)
+		std.SetOutput(out)
+		std.SetFormatter(formatter)
+		std.ReplaceHooks(hooks)
+	}
+}
@@ -0,0 +1,106 @@
+package justlog
+
+import (
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseLevelPrefix(t *testing.T) {
+	type result struct {
+		Level Level
+		Rest  string
+		OK    bool
+	}
+	for line, want := range map[string]result{
+		"[ERROR] db down":      {LogLevelError, "db down", true},
+		"[warn]slow":           {LogLevelWarn, "slow", true},
+		"WARNING: low disk":    {LogLevelWarn, "low disk", true},
+		"debug: cache miss":    {LogLevelDebug, "cache miss", true},
+		"INFO started":         {LogLevelInfo, "started", true},
+		"Info about something": {LogLevelInvalid, "Info about something", false},
+		"[pid 12] started":     {LogLevelInvalid, "[pid 12] started", false},
+		"plain line":           {LogLevelInvalid, "plain line", false},
+		"[unclosed":            {LogLevelInvalid, "[unclosed", false},
+	} {
+		level, rest, ok := parseLevelPrefix(line)
+		assert.Equal(t, want, result{level, rest, ok}, line)
+	}
+}
+
+func Test_stripStdLogTime(t *testing.T) {
+	for line, want := range map[string]string{
+		"2009/01/23 01:23:23 message":        "message",
+		"2009/01/23 01:23:23.123123 message": "message",
+		"01:23:23 message":                   "message",
+		"2009/01/23 message":                 "message",
+		"12:00 is noon":                      "12:00 is noon",
+		"message":                            "message",
+	} {
+		assert.Equal(t, want, stripStdLogTime(line), line)
+	}
+}
+
+func Test_RedirectStdLog(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{ShowNoTime: true})
+	assert.NoError(t, err)
+	var out strings.Builder
+	logger.SetOutput(&out)
+
+	log.SetFlags(log.LstdFlags)
+	restore := RedirectStdLog(logger, LogLevelWarn)
+	log.Print("no level")
+	log.Printf("[DEBUG] filtered")
+	log.Printf("ERROR: connect failed")
+	std := log.New(log.Writer(), "", log.LstdFlags|log.Lmicroseconds)
+	std.Print("info: own flags")
+	restore()
+
+	assert.Equal(t, log.LstdFlags, log.Flags())
+	assert.Equal(t, " [WRN] no level\n [ERR] connect failed\n [INF] own flags\n", stripDelta(out.String()))
+}
+
+func Test_RedirectLogrusGlobal(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{ShowNoTime: true, Level: "debug"})
+	assert.NoError(t, err)
+	var out strings.Builder
+	logger.SetOutput(&out)
+
+	restore := RedirectLogrusGlobal(logger)
+	logrus.Trace("filtered by justlog level")
+	logrus.Debug("debug line")
+	logrus.WithField("user", "joe").Warn("warn line")
+	restore()
+
+	assert.Equal(t, logrus.InfoLevel, logrus.GetLevel())
+	assert.Equal(t, " [DBG] debug line\n [WRN] warn line user=joe\n", stripDelta(out.String()))
+}
+
+func Test_RedirectLogrusGlobal_FatalAsyncFlush(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{ShowNoTime: true, DeltaMode: "none", AsyncQueueSize: 10})
+	assert.NoError(t, err)
+	out := newGateWriter()
+	logger.SetOutput(out)
+
+	std := logrus.StandardLogger()
+	exitFunc := std.ExitFunc
+	defer func() { std.ExitFunc = exitFunc }()
+	var linesAtExit []string
+	std.ExitFunc = func(code int) {
+		assert.Equal(t, 1, code)
+		linesAtExit = out.Lines()
+	}
+
+	restore := RedirectLogrusGlobal(logger)
+	defer restore()
+	time.AfterFunc(50*time.Millisecond, func() { close(out.gate) })
+	logrus.Info("info line")
+	logrus.Fatal("fatal line")
+
+	assert.Equal(t, []string{"[INF] info line\n", "[ERR][FATAL] fatal line\n"}, linesAtExit)
+	assert.NoError(t, logger.Close())
+}
@@ -0,0 +1,96 @@
+package justlog
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RotatingFileWriter_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	w, err := NewRotatingFileWriter(&LoggerConfig{File: path})
+	assert.NoError(t, err)
+
+	w.Write([]byte("before\n"))
+	assert.NoError(t, os.Rename(path, path+".1"))
+	w.Write([]byte("moved\n"))
+	assert.NoError(t, w.Reopen())
+	w.Write([]byte("after\n"))
+	assert.NoError(t, w.Close())
+
+	assert.Equal(t, map[string]string{
+		"app.log":   "after\n",
+		"app.log.1": "before\nmoved\n",
+	}, readLogDir(t, dir))
+
+	assert.Equal(t, os.ErrClosed, w.Reopen())
+}
+
+func Test_RotatingFileWriter_Reopen_KeepsOldOnError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sub", "app.log")
+	assert.NoError(t, os.Mkdir(filepath.Dir(path), 0755))
+
+	w, err := NewRotatingFileWriter(&LoggerConfig{File: path})
+	assert.NoError(t, err)
+
+	assert.NoError(t, os.Rename(filepath.Dir(path), filepath.Join(dir, "moved")))
+	assert.Error(t, w.Reopen())
+
+	_, err = w.Write([]byte("still written\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	content, err := os.ReadFile(filepath.Join(dir, "moved", "app.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, "still written\n", string(content))
+}
+
+func Test_FmtBasedLogger_ReopenOnSIGHUP(t *testing.T) {
+	for _, asyncQueueSize := range []int{0, 8} {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+
+		logger, err := NewFmtBasedLogger(LoggerConfig{
+			ShowNoTime:         true,
+			File:               path,
+			FileReopenOnSIGHUP: true,
+			AsyncQueueSize:     asyncQueueSize,
+		})
+		assert.NoError(t, err)
+
+		logger.Info("before")
+		assert.NoError(t, logger.Flush())
+		assert.NoError(t, os.Rename(path, path+".1"))
+
+		process, err := os.FindProcess(os.Getpid())
+		assert.NoError(t, err)
+		assert.NoError(t, process.Signal(syscall.SIGHUP))
+
+		for attempt := 0; attempt < 200; attempt++ {
+			if _, err := os.Stat(path); err == nil {
+				break
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+
+		logger.Info("after")
+		assert.NoError(t, logger.Close())
+
+		files := readLogDir(t, dir)
+		assert.Regexp(t, `\[INF\] before\n$`, files["app.log.1"])
+		assert.Regexp(t, `\[INF\] after\n$`, files["app.log"])
+	}
+}
+
+func Test_FmtBasedLogger_Reopen_NotReopener(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{})
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Reopen())
+}
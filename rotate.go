@@ -0,0 +1,373 @@
+package justlog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	RotateNever  = ""
+	RotateHourly = "hourly"
+	RotateDaily  = "daily"
+)
+
+const (
+	backupTimeFormat = "2006-01-02T15-04-05.000000"
+	gzipSuffix       = ".gz"
+)
+
+// RotatingFileWriter appends to a file and moves it aside to a timestamped
+// backup when it grows over MaxSize or when the rotation period ends. The
+// check is done before each Write, and FmtBasedLogger calls Write with one
+// whole line under outMu, so lines are never split between files. If the
+// rotation fails, Write still writes the line to the current file and
+// returns the rotation error.
+//
+// Backups are compressed and removed by retention limits in a background
+// goroutine. Its errors are passed to ErrorFunc.
+type RotatingFileWriter struct {
+	Path         string
+	MaxSize      int64
+	Every        string
+	MaxBackups   int
+	MaxAge       time.Duration
+	MaxTotalSize int64
+	Compress     bool
+	ErrorFunc    func(error)
+
+	mu         sync.Mutex
+	file       *os.File
+	size       int64
+	nextRotate time.Time
+
+	maintainCh   chan time.Time
+	maintainDone chan struct{}
+}
+
+func NewRotatingFileWriter(cfg *LoggerConfig) (*RotatingFileWriter, error) {
+	w := &RotatingFileWriter{
+		Path:         cfg.File,
+		MaxSize:      cfg.FileMaxSize,
+		Every:        cfg.FileRotate,
+		MaxBackups:   cfg.FileMaxBackups,
+		MaxAge:       cfg.FileMaxAge,
+		MaxTotalSize: cfg.FileMaxTotalSize,
+		Compress:     cfg.FileCompress,
+	}
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Open opens Path for appending. It is called by NewRotatingFileWriter and
+// is only needed for writers built as struct literals.
+func (w *RotatingFileWriter) Open() error {
+	switch w.Every {
+	case RotateNever, RotateHourly, RotateDaily:
+	default:
+		return fmt.Errorf("invalid rotate value: %q", w.Every)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.open(time.Now()); err != nil {
+		return err
+	}
+	w.maintainCh = make(chan time.Time, 1)
+	w.maintainDone = make(chan struct{})
+	go w.maintain(w.maintainCh, w.maintainDone)
+	return nil
+}
+
+func (w *RotatingFileWriter) open(now time.Time) error {
+	file, size, err := w.openFile()
+	if err != nil {
+		return err
+	}
+	w.file = file
+	w.size = size
+	w.nextRotate = nextRotateTime(now, w.Every)
+	return nil
+}
+
+// openFile opens Path for appending and returns it with its size.
+func (w *RotatingFileWriter) openFile() (*os.File, int64, error) {
+	file, err := os.OpenFile(w.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, 0, fmt.Errorf("open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, fmt.Errorf("stat log file: %w", err)
+	}
+	return file, info.Size(), nil
+}
+
+func nextRotateTime(now time.Time, period string) time.Time {
+	year, month, day := now.Date()
+	switch period {
+	case RotateHourly:
+		return time.Date(year, month, day, now.Hour()+1, 0, 0, 0, now.Location())
+	case RotateDaily:
+		return time.Date(year, month, day+1, 0, 0, 0, 0, now.Location())
+	}
+	return time.Time{}
+}
+
+func (w *RotatingFileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	now := time.Now()
+	var rotateErr error
+	if w.needRotate(len(p), now) {
+		rotateErr = w.rotate(now)
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+func (w *RotatingFileWriter) needRotate(writeLen int, now time.Time) bool {
+	if !w.nextRotate.IsZero() && !now.Before(w.nextRotate) {
+		return true
+	}
+	return w.MaxSize > 0 && w.size > 0 && w.size+int64(writeLen) > w.MaxSize
+}
+
+// Reopener is an output able to reopen its file after it was moved away by
+// an external tool like logrotate.
+type Reopener interface {
+	Reopen() error
+}
+
+// Reopen opens Path again and closes the previous descriptor. If Path can
+// not be opened the previous file is kept and an error is returned.
+func (w *RotatingFileWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return os.ErrClosed
+	}
+
+	file, size, err := w.openFile()
+	if err != nil {
+		return err
+	}
+	old := w.file
+	w.file = file
+	w.size = size
+	return old.Close()
+}
+
+// Rotate moves current file to a backup and opens a new one.
+func (w *RotatingFileWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	return w.rotate(time.Now())
+}
+
+// rotate keeps the current file open until the new one is opened, so a
+// failed rotation leaves the writer usable and is retried by the next Write.
+func (w *RotatingFileWriter) rotate(now time.Time) error {
+	backup := w.Path + "." + now.Format(backupTimeFormat)
+	if err := os.Rename(w.Path, backup); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("rename log file: %w", err)
+	}
+	file, size, err := w.openFile()
+	if err != nil {
+		return err
+	}
+	old := w.file
+	w.file = file
+	w.size = size
+	w.nextRotate = nextRotateTime(now, w.Every)
+
+	select {
+	case w.maintainCh <- now:
+	default:
+		// maintenance is already pending and will see the new backup
+	}
+
+	if err := old.Close(); err != nil {
+		return fmt.Errorf("close log file: %w", err)
+	}
+	return nil
+}
+
+func (w *RotatingFileWriter) maintain(maintainCh <-chan time.Time, done chan struct{}) {
+	defer close(done)
+	for now := range maintainCh {
+		if w.Compress {
+			w.compressBackups()
+		}
+		w.removeOldBackups(now)
+	}
+}
+
+func (w *RotatingFileWriter) reportError(err error) {
+	if w.ErrorFunc != nil {
+		w.ErrorFunc(err)
+	}
+}
+
+type logBackup struct {
+	path string
+	time time.Time
+	size int64
+}
+
+// backups returns existing backups of Path, newest first.
+func (w *RotatingFileWriter) backups() ([]logBackup, error) {
+	dir, base := filepath.Split(w.Path)
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("list log backups: %w", err)
+	}
+
+	prefix := base + "."
+	var backups []logBackup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), gzipSuffix)
+		backupTime, err := time.ParseInLocation(backupTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, logBackup{
+			path: filepath.Join(dir, name),
+			time: backupTime,
+			size: info.Size(),
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].time.After(backups[j].time)
+	})
+	return backups, nil
+}
+
+func (w *RotatingFileWriter) compressBackups() {
+	backups, err := w.backups()
+	if err != nil {
+		w.reportError(err)
+		return
+	}
+	for _, backup := range backups {
+		if strings.HasSuffix(backup.path, gzipSuffix) {
+			continue
+		}
+		if err := compressFile(backup.path); err != nil {
+			w.reportError(fmt.Errorf("compress log backup: %w", err))
+		}
+	}
+}
+
+func compressFile(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmpPath := path + gzipSuffix + ".tmp"
+	dst, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			dst.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	zw := gzip.NewWriter(dst)
+	if _, err = io.Copy(zw, src); err != nil {
+		return err
+	}
+	if err = zw.Close(); err != nil {
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmpPath, path+gzipSuffix); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func (w *RotatingFileWriter) removeOldBackups(now time.Time) {
+	if w.MaxBackups <= 0 && w.MaxAge <= 0 && w.MaxTotalSize <= 0 {
+		return
+	}
+	backups, err := w.backups()
+	if err != nil {
+		w.reportError(err)
+		return
+	}
+	var totalSize int64
+	for i, backup := range backups {
+		totalSize += backup.size
+		tooMany := w.MaxBackups > 0 && i >= w.MaxBackups
+		tooOld := w.MaxAge > 0 && now.Sub(backup.time) > w.MaxAge
+		tooBig := w.MaxTotalSize > 0 && totalSize > w.MaxTotalSize
+		if !tooMany && !tooOld && !tooBig {
+			continue
+		}
+		if err := os.Remove(backup.path); err != nil {
+			w.reportError(fmt.Errorf("remove log backup: %w", err))
+		}
+	}
+}
+
+// Close closes the file and waits for background maintenance to finish.
+func (w *RotatingFileWriter) Close() error {
+	w.mu.Lock()
+	if w.maintainCh == nil {
+		w.mu.Unlock()
+		return os.ErrClosed
+	}
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	close(w.maintainCh)
+	w.maintainCh = nil
+	done := w.maintainDone
+	w.mu.Unlock()
+
+	<-done
+	return err
+}
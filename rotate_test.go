@@ -0,0 +1,336 @@
+package justlog
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func readLogDir(t *testing.T, dir string) map[string]string {
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	files := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		assert.NoError(t, err)
+		files[entry.Name()] = string(content)
+	}
+	return files
+}
+
+func sortedKeys(files map[string]string) []string {
+	keys := make([]string, 0, len(files))
+	for key := range files {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type TestCase_RotatingFileWriter struct {
+	Config       LoggerConfig
+	Writes       []string
+	TimeSequence []time.Time
+	WantFiles    map[string]string
+}
+
+func (tc TestCase_RotatingFileWriter) Run(t *testing.T) {
+	dir := t.TempDir()
+
+	patches := gomonkey.NewPatches()
+	defer patches.Reset()
+	if tc.TimeSequence != nil {
+		timeSequence := make([]gomonkey.OutputCell, 0, len(tc.TimeSequence))
+		for _, timeValue := range tc.TimeSequence {
+			timeSequence = append(timeSequence, gomonkey.OutputCell{Values: gomonkey.Params{timeValue}})
+		}
+		patches.ApplyFuncSeq(time.Now, timeSequence)
+	}
+
+	cfg := tc.Config
+	cfg.File = filepath.Join(dir, "app.log")
+	w, err := NewRotatingFileWriter(&cfg)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, line := range tc.Writes {
+		n, err := w.Write([]byte(line))
+		assert.NoError(t, err)
+		assert.Equal(t, len(line), n)
+	}
+	assert.NoError(t, w.Close())
+
+	assert.Equal(t, tc.WantFiles, readLogDir(t, dir))
+}
+
+func Test_RotatingFileWriter_MaxSize(t *testing.T) {
+	at := time.Date(2021, time.Month(2), 1, 3, 4, 5, 6000, time.Local)
+	TestCase_RotatingFileWriter{
+		Config: LoggerConfig{FileMaxSize: 10},
+		Writes: []string{"line1\n", "line2\n", "line3\n", "a very long line\n", "line5\n"},
+		TimeSequence: []time.Time{
+			at,
+			at,
+			at.Add(1 * time.Second),
+			at.Add(2 * time.Second),
+			at.Add(3 * time.Second),
+			at.Add(4 * time.Second),
+		},
+		WantFiles: map[string]string{
+			"app.log":                            "line5\n",
+			"app.log.2021-02-01T03-04-06.000006": "line1\n",
+			"app.log.2021-02-01T03-04-07.000006": "line2\n",
+			"app.log.2021-02-01T03-04-08.000006": "line3\n",
+			"app.log.2021-02-01T03-04-09.000006": "a very long line\n",
+		},
+	}.Run(t)
+}
+
+func Test_RotatingFileWriter_MaxBackups(t *testing.T) {
+	at := time.Date(2021, time.Month(2), 1, 3, 4, 5, 0, time.Local)
+	TestCase_RotatingFileWriter{
+		Config: LoggerConfig{FileMaxSize: 1, FileMaxBackups: 2},
+		Writes: []string{"1\n", "2\n", "3\n", "4\n"},
+		TimeSequence: []time.Time{
+			at,
+			at,
+			at.Add(1 * time.Second),
+			at.Add(2 * time.Second),
+			at.Add(3 * time.Second),
+		},
+		WantFiles: map[string]string{
+			"app.log":                            "4\n",
+			"app.log.2021-02-01T03-04-07.000000": "2\n",
+			"app.log.2021-02-01T03-04-08.000000": "3\n",
+		},
+	}.Run(t)
+}
+
+func Test_RotatingFileWriter_Daily(t *testing.T) {
+	at := time.Date(2021, time.Month(2), 1, 23, 59, 59, 0, time.Local)
+	TestCase_RotatingFileWriter{
+		Config: LoggerConfig{FileRotate: RotateDaily},
+		Writes: []string{"day1 a\n", "day1 b\n", "day2\n"},
+		TimeSequence: []time.Time{
+			at,
+			at,
+			at.Add(500 * time.Millisecond),
+			at.Add(time.Second),
+		},
+		WantFiles: map[string]string{
+			"app.log":                            "day2\n",
+			"app.log.2021-02-02T00-00-00.000000": "day1 a\nday1 b\n",
+		},
+	}.Run(t)
+}
+
+func Test_RotatingFileWriter_Hourly_MaxAge(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	assert.NoError(t, os.WriteFile(path+".2021-01-01T00-00-00.000000", []byte("old\n"), 0644))
+	assert.NoError(t, os.WriteFile(path+".not-a-backup", []byte("keep\n"), 0644))
+
+	patches := gomonkey.NewPatches()
+	defer patches.Reset()
+	now := time.Date(2021, time.Month(2), 1, 3, 59, 0, 0, time.Local)
+	patches.ApplyFunc(time.Now, func() time.Time { return now })
+
+	w, err := NewRotatingFileWriter(&LoggerConfig{File: path, FileRotate: RotateHourly, FileMaxAge: 24 * time.Hour})
+	assert.NoError(t, err)
+	w.Write([]byte("hour3\n"))
+	now = now.Add(time.Minute)
+	w.Write([]byte("hour4\n"))
+	assert.NoError(t, w.Close())
+
+	assert.Equal(t, map[string]string{
+		"app.log":                            "hour4\n",
+		"app.log.2021-02-01T04-00-00.000000": "hour3\n",
+		"app.log.not-a-backup":               "keep\n",
+	}, readLogDir(t, dir))
+}
+
+func Test_RotatingFileWriter_RotateFailure_Recovers(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sub", "app.log")
+	assert.NoError(t, os.Mkdir(filepath.Dir(path), 0755))
+
+	w, err := NewRotatingFileWriter(&LoggerConfig{File: path, FileMaxSize: 1})
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("1\n"))
+	assert.NoError(t, err)
+
+	// the new file can not be opened while the directory is away
+	assert.NoError(t, os.Rename(filepath.Dir(path), filepath.Join(dir, "moved")))
+	n, err := w.Write([]byte("2\n"))
+	assert.Error(t, err)
+	assert.Equal(t, 2, n)
+	assert.Error(t, w.Reopen())
+
+	assert.NoError(t, os.Mkdir(filepath.Dir(path), 0755))
+	_, err = w.Write([]byte("3\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	assert.Equal(t, os.ErrClosed, w.Close())
+
+	assert.Equal(t, map[string]string{"app.log": "3\n"}, readLogDir(t, filepath.Dir(path)))
+	assert.Equal(t, map[string]string{"app.log": "1\n2\n"}, readLogDir(t, filepath.Join(dir, "moved")))
+}
+
+func Test_FmtBasedLogger_File_RotateErrorReported(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sub", "app.log")
+	assert.NoError(t, os.Mkdir(filepath.Dir(path), 0755))
+
+	logger, err := NewFmtBasedLogger(LoggerConfig{ShowNoTime: true, DeltaMode: "none", File: path, FileMaxSize: 1})
+	assert.NoError(t, err)
+	var errs []error
+	logger.ErrorFunc = func(err error) { errs = append(errs, err) }
+	logger.Info("first")
+	assert.Empty(t, errs)
+
+	// the new file can not be opened while the directory is away
+	assert.NoError(t, os.Rename(filepath.Dir(path), filepath.Join(dir, "moved")))
+	logger.Info("second")
+	logger.Infof("third %d", 3)
+	logger.LogFields(LogLevelInfo, "fourth")
+	if assert.Len(t, errs, 3) {
+		assert.True(t, errors.Is(errs[0], os.ErrNotExist), errs[0].Error())
+	}
+	assert.NoError(t, logger.Close())
+
+	assert.Equal(t, map[string]string{"app.log": "[INF] first\n[INF] second\n[INF] third 3\n[INF] fourth\n"},
+		readLogDir(t, filepath.Join(dir, "moved")))
+}
+
+func Test_RotatingFileWriter_InvalidRotate(t *testing.T) {
+	_, err := NewRotatingFileWriter(&LoggerConfig{File: filepath.Join(t.TempDir(), "app.log"), FileRotate: "weekly"})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `"weekly"`)
+	}
+}
+
+func Test_FmtBasedLogger_File(t *testing.T) {
+	dir := t.TempDir()
+	logger, err := NewFmtBasedLogger(LoggerConfig{
+		ShowNoTime:  true,
+		File:        filepath.Join(dir, "app.log"),
+		FileMaxSize: 40,
+	})
+	assert.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		logger.Infof("line %d", i)
+	}
+	assert.NoError(t, logger.Close())
+
+	files := readLogDir(t, dir)
+	var all []string
+	for _, name := range sortedKeys(files) {
+		content := files[name]
+		assert.True(t, strings.HasSuffix(content, "\n"), name)
+		assert.True(t, len(content) <= 40 || strings.Count(content, "\n") == 1, name)
+		all = append(all, strings.Split(strings.TrimSuffix(content, "\n"), "\n")...)
+	}
+	assert.Len(t, all, 10)
+}
+
+func readGzipFile(t *testing.T, path string) string {
+	file, err := os.Open(path)
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	defer file.Close()
+	zr, err := gzip.NewReader(file)
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	content, err := io.ReadAll(zr)
+	assert.NoError(t, err)
+	return string(content)
+}
+
+func Test_RotatingFileWriter_Compress(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	patches := gomonkey.NewPatches()
+	defer patches.Reset()
+	now := time.Date(2021, time.Month(2), 1, 3, 4, 5, 0, time.Local)
+	patches.ApplyFunc(time.Now, func() time.Time { return now })
+
+	w, err := NewRotatingFileWriter(&LoggerConfig{File: path, FileMaxSize: 1, FileCompress: true})
+	assert.NoError(t, err)
+	for _, line := range []string{"1\n", "2\n", "3\n"} {
+		w.Write([]byte(line))
+		now = now.Add(time.Second)
+	}
+	assert.NoError(t, w.Close())
+
+	assert.Equal(t, []string{
+		"app.log",
+		"app.log.2021-02-01T03-04-06.000000.gz",
+		"app.log.2021-02-01T03-04-07.000000.gz",
+	}, sortedKeys(readLogDir(t, dir)))
+	assert.Equal(t, "1\n", readGzipFile(t, path+".2021-02-01T03-04-06.000000.gz"))
+	assert.Equal(t, "2\n", readGzipFile(t, path+".2021-02-01T03-04-07.000000.gz"))
+}
+
+func Test_RotatingFileWriter_MaxTotalSize(t *testing.T) {
+	at := time.Date(2021, time.Month(2), 1, 3, 4, 5, 0, time.Local)
+	TestCase_RotatingFileWriter{
+		Config: LoggerConfig{FileMaxSize: 1, FileMaxTotalSize: 7},
+		Writes: []string{"111\n", "222\n", "333\n", "444\n"},
+		TimeSequence: []time.Time{
+			at,
+			at,
+			at.Add(1 * time.Second),
+			at.Add(2 * time.Second),
+			at.Add(3 * time.Second),
+		},
+		WantFiles: map[string]string{
+			"app.log":                            "444\n",
+			"app.log.2021-02-01T03-04-08.000000": "333\n",
+		},
+	}.Run(t)
+}
+
+func Test_FmtBasedLogger_File_CompressErrorReported(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	patches := gomonkey.NewPatches()
+	defer patches.Reset()
+	now := time.Date(2021, time.Month(2), 1, 3, 4, 5, 0, time.Local)
+	patches.ApplyFunc(time.Now, func() time.Time { return now })
+
+	// a directory in place of the temporary file makes compression fail
+	assert.NoError(t, os.Mkdir(path+".2021-02-01T03-04-05.000000.gz.tmp", 0755))
+
+	logger, err := NewFmtBasedLogger(LoggerConfig{ShowNoTime: true, File: path, FileCompress: true})
+	assert.NoError(t, err)
+	logger.Info("first")
+	file := logger.Out.(*RotatingFileWriter)
+	assert.NoError(t, file.Rotate())
+
+	// time.Now is patched, so wait by attempts count
+	var content []byte
+	for attempt := 0; attempt < 200; attempt++ {
+		content, err = os.ReadFile(path)
+		assert.NoError(t, err)
+		if strings.Contains(string(content), "compress log backup") {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	assert.Regexp(t, `^\[\+\d+\.\d{6}\] \[ERR\] log file maintenance: compress log backup: .*\.gz\.tmp: is a directory\n$`, string(content))
+	assert.NoError(t, logger.Close())
+}
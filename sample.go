@@ -0,0 +1,212 @@
+package justlog
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const DefaultSampleInterval = time.Second
+
+// SampleRate makes Sampler write First lines of a kind per interval and
+// then every Thereafter-th one. Zero Thereafter suppresses the rest.
+type SampleRate struct {
+	First      int
+	Thereafter int
+}
+
+// Sampler limits lines written per interval. Lines are counted separately
+// per level and per format string, lines logged without format (Info, Error
+// and so on) are counted per call site. With ByCaller set, all lines are
+// counted per call site.
+//
+// Suppressed lines are reported by a warning written with the first line
+// of the next interval.
+type Sampler struct {
+	Interval time.Duration
+	// Rates holds rate per level, lines of levels not listed are not
+	// sampled.
+	Rates    map[Level]SampleRate
+	ByCaller bool
+
+	mu     sync.Mutex
+	start  time.Time
+	counts map[sampleKey]*sampleCount
+}
+
+type sampleKey struct {
+	level  Level
+	format string
+	pc     uintptr
+}
+
+type sampleCount struct {
+	seen       uint64
+	suppressed uint64
+}
+
+// SampleReport is the number of lines of one kind suppressed during
+// the last interval.
+type SampleReport struct {
+	Level      Level
+	Key        string
+	Suppressed uint64
+}
+
+func NewSampler(interval time.Duration, rates map[Level]SampleRate) *Sampler {
+	if interval <= 0 {
+		interval = DefaultSampleInterval
+	}
+	return &Sampler{
+		Interval: interval,
+		Rates:    rates,
+	}
+}
+
+// NewSamplerFromConfig returns nil when cfg.SampleFirst is not set. Lines of
+// cfg.SampleLevel and lower levels are sampled.
+func NewSamplerFromConfig(cfg *LoggerConfig) (*Sampler, error) {
+	if cfg.SampleFirst <= 0 {
+		return nil, nil
+	}
+	maxLevel, err := ParseLogLevel(cfg.SampleLevel)
+	if err != nil {
+		return nil, fmt.Errorf("sample level: %w", err)
+	}
+	rates := make(map[Level]SampleRate)
+	for level := LogLevelTrace; level <= maxLevel; level++ {
+		rates[level] = SampleRate{First: cfg.SampleFirst, Thereafter: cfg.SampleThereafter}
+	}
+	sampler := NewSampler(cfg.SampleInterval, rates)
+	sampler.ByCaller = cfg.SampleByCaller
+	return sampler, nil
+}
+
+// Check tells whether the line should be written. When the interval rolled
+// over, it also returns reports of lines suppressed in the previous one.
+// pc is the call site, used as the key when format is empty or ByCaller is
+// set.
+func (sampler *Sampler) Check(level Level, format string, pc uintptr, now time.Time) (bool, []SampleReport) {
+	rate, ok := sampler.Rates[level]
+	if !ok {
+		return true, nil
+	}
+	key := sampleKey{level: level, format: format}
+	if format == "" || sampler.ByCaller {
+		key = sampleKey{level: level, pc: pc}
+	}
+
+	sampler.mu.Lock()
+	defer sampler.mu.Unlock()
+
+	var reports []SampleReport
+	if now.Sub(sampler.start) >= sampler.Interval || now.Before(sampler.start) {
+		reports = sampler.rollover()
+		sampler.start = now
+	}
+
+	count := sampler.counts[key]
+	if count == nil {
+		if sampler.counts == nil {
+			sampler.counts = make(map[sampleKey]*sampleCount)
+		}
+		count = &sampleCount{}
+		sampler.counts[key] = count
+	}
+	count.seen++
+	if count.seen <= uint64(rate.First) {
+		return true, reports
+	}
+	if rate.Thereafter > 0 && (count.seen-uint64(rate.First))%uint64(rate.Thereafter) == 0 {
+		return true, reports
+	}
+	count.suppressed++
+	return false, reports
+}
+
+// rollover resets counters, keys not seen during the interval are
+// forgotten.
+func (sampler *Sampler) rollover() []SampleReport {
+	var reports []SampleReport
+	for key, count := range sampler.counts {
+		if count.seen == 0 {
+			delete(sampler.counts, key)
+			continue
+		}
+		if count.suppressed > 0 {
+			reports = append(reports, SampleReport{
+				Level:      key.level,
+				Key:        key.String(),
+				Suppressed: count.suppressed,
+			})
+		}
+		*count = sampleCount{}
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Level != reports[j].Level {
+			return reports[i].Level < reports[j].Level
+		}
+		return reports[i].Key < reports[j].Key
+	})
+	return reports
+}
+
+func (key sampleKey) String() string {
+	if key.pc == 0 {
+		return key.format
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{key.pc}).Next()
+	if frame.File == "" {
+		return "???:0"
+	}
+	return filepath.Base(frame.File) + ":" + strconv.Itoa(frame.Line)
+}
+
+func callerPC(depth int) uintptr {
+	var pcs [1]uintptr
+	if runtime.Callers(depth+2, pcs[:]) == 0 {
+		return 0
+	}
+	return pcs[0]
+}
+
+// WithSampler returns child logger with its own sampler, nil sampler turns
+// sampling off for the child. Children of the result share the sampler.
+func (logger *FmtBasedLogger) WithSampler(sampler *Sampler) *FmtBasedLogger {
+	child := logger.child()
+	child.sampler = sampler
+	return child
+}
+
+// sample applies sampler of logger to the line and writes reports of the
+// previous interval, if any. The caller of the line is depth frames up.
+func (logger *FmtBasedLogger) sample(level Level, format string, now time.Time, depth int) bool {
+	if logger.sampler == nil {
+		return true
+	}
+	var pc uintptr
+	if format == "" || logger.sampler.ByCaller {
+		pc = callerPC(depth)
+	}
+	ok, reports := logger.sampler.Check(level, format, pc, now)
+	for _, report := range reports {
+		logger.writeSampleReport(report, now)
+	}
+	return ok
+}
+
+func (logger *FmtBasedLogger) writeSampleReport(report SampleReport, now time.Time) {
+	rec := Record{
+		Message: []byte("sampling suppressed " + strconv.FormatUint(report.Suppressed, 10) + " lines"),
+		Fields: []Field{
+			{Key: "sampled_key", Value: report.Key},
+			{Key: "sampled_level", Value: report.Level.String()},
+		},
+	}
+	logger.fillRecord(&rec, LogLevelWarn, now)
+	logger.writeRecord(&rec)
+}
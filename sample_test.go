@@ -0,0 +1,100 @@
+package justlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Sampler_Check_FirstThenEveryNth(t *testing.T) {
+	sampler := NewSampler(time.Second, map[Level]SampleRate{LogLevelInfo: {First: 2, Thereafter: 3}})
+	now := time.Date(2021, time.Month(2), 1, 3, 4, 5, 0, time.UTC)
+
+	var written []int
+	for i := 1; i <= 10; i++ {
+		ok, reports := sampler.Check(LogLevelInfo, "tick %d", 0, now)
+		assert.Nil(t, reports)
+		if ok {
+			written = append(written, i)
+		}
+	}
+	assert.Equal(t, []int{1, 2, 5, 8}, written)
+
+	ok, _ := sampler.Check(LogLevelWarn, "tick %d", 0, now)
+	assert.True(t, ok, "level without rate is not sampled")
+
+	ok, reports := sampler.Check(LogLevelInfo, "tick %d", 0, now.Add(time.Second))
+	assert.True(t, ok, "counters are reset on new interval")
+	assert.Equal(t, []SampleReport{{Level: LogLevelInfo, Key: "tick %d", Suppressed: 6}}, reports)
+}
+
+func Test_Sampler_Check_ForgetsIdleKeys(t *testing.T) {
+	sampler := NewSampler(time.Second, map[Level]SampleRate{LogLevelInfo: {First: 1}})
+	now := time.Date(2021, time.Month(2), 1, 3, 4, 5, 0, time.UTC)
+
+	sampler.Check(LogLevelInfo, "a", 0, now)
+	sampler.Check(LogLevelInfo, "b", 0, now.Add(time.Second))
+	sampler.Check(LogLevelInfo, "b", 0, now.Add(2*time.Second))
+	assert.Len(t, sampler.counts, 1)
+}
+
+func Test_FmtBasedLogger_Sampling(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{
+		ShowNoTime:     true,
+		SampleFirst:    2,
+		SampleInterval: time.Minute,
+	})
+	assert.NoError(t, err)
+	var out strings.Builder
+	logger.SetOutput(&out)
+
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		logger.WriteMessagef(LogLevelInfo, now, "row %d", i)
+		logger.Info("plain")
+		logger.Warn("warnings are not sampled")
+	}
+	logger.WriteMessagef(LogLevelInfo, now.Add(time.Minute), "row %d", 5)
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	want := []string{
+		"[INF] row 0",
+		"[INF] plain",
+		"[WRN] warnings are not sampled",
+		"[INF] row 1",
+		"[INF] plain",
+		"[WRN] warnings are not sampled",
+		"[WRN] warnings are not sampled",
+		"[WRN] warnings are not sampled",
+		"[WRN] warnings are not sampled",
+		"[WRN] sampling suppressed 3 lines sampled_key=\"row %d\" sampled_level=info",
+		"[WRN] sampling suppressed 3 lines sampled_key=sample_test.go:56 sampled_level=info",
+		"[INF] row 5",
+	}
+	if assert.Len(t, lines, len(want), out.String()) {
+		for i := range want {
+			assert.True(t, strings.HasSuffix(lines[i], want[i]), lines[i])
+		}
+	}
+}
+
+func Test_FmtBasedLogger_WithSampler(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{ShowNoTime: true, SampleFirst: 1})
+	assert.NoError(t, err)
+	var out strings.Builder
+	logger.SetOutput(&out)
+
+	unsampled := logger.WithSampler(nil).Named("db")
+	for i := 0; i < 3; i++ {
+		unsampled.Infof("query %d", i)
+		logger.Infof("query %d", i)
+	}
+	assert.Equal(t, 4, strings.Count(out.String(), "\n"), out.String())
+}
+
+func Test_NewFmtBasedLogger_SampleLevel_Invalid(t *testing.T) {
+	_, err := NewFmtBasedLogger(LoggerConfig{SampleFirst: 1, SampleLevel: "loud"})
+	assert.Error(t, err)
+}
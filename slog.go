@@ -0,0 +1,249 @@
+//go:build go1.21
+// +build go1.21
+
+package justlog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// LevelFromSlog maps slog levels onto justlog ones. Levels between the
+// named slog levels go down to the closest lower justlog level.
+func LevelFromSlog(level slog.Level) Level {
+	switch {
+	case level < slog.LevelDebug:
+		return LogLevelTrace
+	case level < slog.LevelInfo:
+		return LogLevelDebug
+	case level < slog.LevelWarn:
+		return LogLevelInfo
+	case level < slog.LevelError:
+		return LogLevelWarn
+	case level < slog.LevelError+4:
+		return LogLevelError
+	}
+	return LogLevelFatal
+}
+
+func LevelToSlog(level Level) slog.Level {
+	switch level {
+	case LogLevelTrace:
+		return slog.LevelDebug - 4
+	case LogLevelDebug:
+		return slog.LevelDebug
+	case LogLevelInfo:
+		return slog.LevelInfo
+	case LogLevelWarn:
+		return slog.LevelWarn
+	case LogLevelError:
+		return slog.LevelError
+	}
+	return slog.LevelError + 4
+}
+
+// SlogHandler is a slog.Handler writing records through FmtBasedLogger, so
+// they look the same as lines logged by its own methods. Attributes become
+// fields, keys of grouped attributes are joined with dots.
+type SlogHandler struct {
+	logger *FmtBasedLogger
+	fields []Field
+	group  string
+}
+
+func NewSlogHandler(logger *FmtBasedLogger) *SlogHandler {
+	return &SlogHandler{logger: logger}
+}
+
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.logger.Enabled(LevelFromSlog(level))
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	level := LevelFromSlog(r.Level)
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	rec := getRecord()
+	defer putRecord(rec)
+	h.logger.fillRecord(rec, level, t)
+	if flow := FlowFromContext(ctx); flow != nil {
+		rec.Flow = flow
+	}
+	msg := getMessageBuffer()
+	defer putMessageBuffer(msg)
+	msg.WriteString(r.Message)
+	rec.Message = msg.Bytes()
+
+	fields := make([]Field, 0, len(h.logger.fields)+len(h.fields)+r.NumAttrs())
+	fields = append(fields, h.logger.fields...)
+	fields = append(fields, h.fields...)
+	r.Attrs(func(attr slog.Attr) bool {
+		fields = appendSlogAttr(fields, h.group, attr)
+		return true
+	})
+	rec.Fields = fields
+
+	if h.logger.root().ReportCaller && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		rec.Caller = filepath.Base(frame.File) + ":" + strconv.Itoa(frame.Line)
+	}
+	return h.logger.logRecord(rec)
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	fields := make([]Field, len(h.fields), len(h.fields)+len(attrs))
+	copy(fields, h.fields)
+	for _, attr := range attrs {
+		fields = appendSlogAttr(fields, h.group, attr)
+	}
+	return &SlogHandler{logger: h.logger, fields: fields, group: h.group}
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SlogHandler{logger: h.logger, fields: h.fields, group: h.group + name + "."}
+}
+
+func appendSlogAttr(fields []Field, prefix string, attr slog.Attr) []Field {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, groupAttr := range attr.Value.Group() {
+			fields = appendSlogAttr(fields, prefix, groupAttr)
+		}
+		return fields
+	}
+	return append(fields, Field{Key: prefix + attr.Key, Value: attr.Value.Any()})
+}
+
+// SlogLogger exposes slog.Logger as justlog Logger.
+type SlogLogger struct {
+	Logger *slog.Logger
+}
+
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	return &SlogLogger{Logger: logger}
+}
+
+// slogCallerDepth is the number of frames between callerPC in log and
+// the code calling a logging method.
+const slogCallerDepth = 2
+
+func (logger *SlogLogger) log(level Level, msg string) {
+	ctx := context.Background()
+	slogLevel := LevelToSlog(level)
+	handler := logger.Logger.Handler()
+	if !handler.Enabled(ctx, slogLevel) {
+		return
+	}
+	r := slog.NewRecord(time.Now(), slogLevel, msg, callerPC(slogCallerDepth))
+	handler.Handle(ctx, r)
+}
+
+func (logger *SlogLogger) Trace(args ...interface{}) {
+	logger.log(LogLevelTrace, string(appendMessage(nil, args...)))
+}
+
+func (logger *SlogLogger) Tracef(format string, args ...interface{}) {
+	logger.log(LogLevelTrace, fmt.Sprintf(format, args...))
+}
+
+func (logger *SlogLogger) Debug(args ...interface{}) {
+	logger.log(LogLevelDebug, string(appendMessage(nil, args...)))
+}
+
+func (logger *SlogLogger) Debugf(format string, args ...interface{}) {
+	logger.log(LogLevelDebug, fmt.Sprintf(format, args...))
+}
+
+func (logger *SlogLogger) Info(args ...interface{}) {
+	logger.log(LogLevelInfo, string(appendMessage(nil, args...)))
+}
+
+func (logger *SlogLogger) Infof(format string, args ...interface{}) {
+	logger.log(LogLevelInfo, fmt.Sprintf(format, args...))
+}
+
+func (logger *SlogLogger) Warn(args ...interface{}) {
+	logger.log(LogLevelWarn, string(appendMessage(nil, args...)))
+}
+
+func (logger *SlogLogger) Warnf(format string, args ...interface{}) {
+	logger.log(LogLevelWarn, fmt.Sprintf(format, args...))
+}
+
+func (logger *SlogLogger) Error(args ...interface{}) {
+	logger.log(LogLevelError, string(appendMessage(nil, args...)))
+}
+
+func (logger *SlogLogger) Errorf(format string, args ...interface{}) {
+	logger.log(LogLevelError, fmt.Sprintf(format, args...))
+}
+
+func (logger *SlogLogger) Fatal(args ...interface{}) {
+	logger.log(LogLevelFatal, string(appendMessage(nil, args...)))
+	os.Exit(1)
+}
+
+func (logger *SlogLogger) Fatalf(format string, args ...interface{}) {
+	logger.log(LogLevelFatal, fmt.Sprintf(format, args...))
+	os.Exit(1)
+}
+
+func (logger *SlogLogger) Print(args ...interface{}) {
+	logger.log(LogLevelInfo, string(appendMessage(nil, args...)))
+}
+
+func (logger *SlogLogger) Printf(format string, args ...interface{}) {
+	logger.log(LogLevelInfo, fmt.Sprintf(format, args...))
+}
+
+func (logger *SlogLogger) LogFields(level Level, msg string, fields ...Field) {
+	ctx := context.Background()
+	slogLevel := LevelToSlog(level)
+	handler := logger.Logger.Handler()
+	if !handler.Enabled(ctx, slogLevel) {
+		return
+	}
+	r := slog.NewRecord(time.Now(), slogLevel, msg, callerPC(slogCallerDepth-1))
+	for _, field := range fields {
+		r.AddAttrs(slog.Any(field.Key, field.Interface()))
+	}
+	handler.Handle(ctx, r)
+}
+
+func (logger *SlogLogger) WithField(key string, value interface{}) Logger {
+	return &SlogLogger{Logger: logger.Logger.With(key, value)}
+}
+
+func (logger *SlogLogger) WithFields(fields Fields) Logger {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	args := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		args = append(args, slog.Any(key, fields[key]))
+	}
+	return &SlogLogger{Logger: logger.Logger.With(args...)}
+}
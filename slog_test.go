@@ -0,0 +1,104 @@
+//go:build go1.21
+// +build go1.21
+
+package justlog
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SlogHandler_Handle(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{})
+	assert.NoError(t, err)
+	var out strings.Builder
+	logger.SetOutput(&out)
+	logger.SetPrevTime(time.Date(2021, time.Month(2), 1, 3, 4, 3, 8000000, time.UTC))
+
+	slogger := slog.New(NewSlogHandler(logger.Named("db").WithField("pool", 1).(*FmtBasedLogger)))
+	r := slog.NewRecord(time.Date(2021, time.Month(2), 1, 3, 4, 5, 9000000, time.UTC), slog.LevelWarn, "slow query", 0)
+	r.AddAttrs(slog.Int("ms", 1500), slog.Group("req", slog.String("id", "a1"), slog.Group("", slog.Bool("retry", true))))
+	handler := slogger.With("conn", 7).WithGroup("sql").Handler()
+	assert.NoError(t, handler.Handle(context.Background(), r))
+
+	assert.Equal(t, "2021-02-01 03:04:05.009000[+2.001000] [WRN] [db] slow query pool=1 conn=7 sql.ms=1500 sql.req.id=a1 sql.req.retry=true\n",
+		out.String())
+}
+
+func Test_SlogHandler_Handle_Flow(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{ShowNoTime: true})
+	assert.NoError(t, err)
+	var out strings.Builder
+	logger.SetOutput(&out)
+
+	start := time.Date(2021, time.Month(2), 1, 3, 4, 5, 0, time.UTC)
+	ctx := ContextWithFlow(context.Background(), NewFlow(start))
+	handler := NewSlogHandler(logger)
+	r := slog.NewRecord(start.Add(time.Second), slog.LevelInfo, "started", 0)
+	assert.NoError(t, handler.Handle(ctx, r))
+	r = slog.NewRecord(start.Add(1500*time.Millisecond), slog.LevelInfo, "done", 0)
+	assert.NoError(t, handler.Handle(ctx, r))
+
+	assert.Equal(t, "[+1.000000][=1.000000] [INF] started\n[+0.500000][=1.500000] [INF] done\n", out.String())
+}
+
+func Test_SlogHandler_Enabled(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{Level: "warn"})
+	assert.NoError(t, err)
+	handler := NewSlogHandler(logger)
+	assert.False(t, handler.Enabled(context.Background(), slog.LevelInfo))
+	assert.True(t, handler.Enabled(context.Background(), slog.LevelWarn))
+	assert.True(t, handler.Enabled(context.Background(), slog.LevelError+4))
+}
+
+func Test_LevelFromSlog(t *testing.T) {
+	for level := LogLevelTrace; level <= LogLevelFatal; level++ {
+		assert.Equal(t, level, LevelFromSlog(LevelToSlog(level)))
+	}
+	assert.Equal(t, LogLevelInfo, LevelFromSlog(slog.LevelInfo+2))
+	assert.Equal(t, LogLevelTrace, LevelFromSlog(slog.LevelDebug-1))
+}
+
+func Test_SlogLogger(t *testing.T) {
+	patches := gomonkey.NewPatches()
+	defer patches.Reset()
+	exitCount := 0
+	patches.ApplyFunc(os.Exit, func(code int) {
+		assert.Equal(t, 1, code)
+		exitCount++
+	})
+
+	var out strings.Builder
+	slogger := slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{
+		Level:     LevelToSlog(LogLevelDebug),
+		AddSource: true,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			switch attr.Key {
+			case slog.TimeKey:
+				return slog.Attr{}
+			case slog.SourceKey:
+				source := attr.Value.Any().(*slog.Source)
+				return slog.Bool("from_test", strings.HasSuffix(source.File, "slog_test.go"))
+			}
+			return attr
+		},
+	}))
+
+	var logger Logger = NewSlogLogger(slogger)
+	logger.Trace("filtered")
+	logger.Debugf("debug %d", 1)
+	logger.WithFields(Fields{"b": 2, "a": 1}).Warn("warn ", 2)
+	logger.WithField("k", "v").Fatalf("fatal")
+
+	assert.Equal(t, "level=DEBUG from_test=true msg=\"debug 1\"\n"+
+		"level=WARN from_test=true msg=\"warn 2\" a=1 b=2\n"+
+		"level=ERROR+4 from_test=true msg=fatal k=v\n", out.String())
+	assert.Equal(t, 1, exitCount)
+}
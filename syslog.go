@@ -0,0 +1,342 @@
+package justlog
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	SyslogRFC5424 = "rfc5424"
+	SyslogRFC3164 = "rfc3164"
+)
+
+type SyslogFacility uint8
+
+const (
+	FacilityKern SyslogFacility = iota
+	FacilityUser
+	FacilityMail
+	FacilityDaemon
+	FacilityAuth
+	FacilitySyslog
+	FacilityLpr
+	FacilityNews
+	FacilityUucp
+	FacilityCron
+	FacilityAuthpriv
+	FacilityFtp
+	FacilityLocal0 SyslogFacility = iota + 4
+	FacilityLocal1
+	FacilityLocal2
+	FacilityLocal3
+	FacilityLocal4
+	FacilityLocal5
+	FacilityLocal6
+	FacilityLocal7
+)
+
+var syslogFacilityNames = map[string]SyslogFacility{
+	"kern":     FacilityKern,
+	"user":     FacilityUser,
+	"mail":     FacilityMail,
+	"daemon":   FacilityDaemon,
+	"auth":     FacilityAuth,
+	"syslog":   FacilitySyslog,
+	"lpr":      FacilityLpr,
+	"news":     FacilityNews,
+	"uucp":     FacilityUucp,
+	"cron":     FacilityCron,
+	"authpriv": FacilityAuthpriv,
+	"ftp":      FacilityFtp,
+	"local0":   FacilityLocal0,
+	"local1":   FacilityLocal1,
+	"local2":   FacilityLocal2,
+	"local3":   FacilityLocal3,
+	"local4":   FacilityLocal4,
+	"local5":   FacilityLocal5,
+	"local6":   FacilityLocal6,
+	"local7":   FacilityLocal7,
+}
+
+func ParseSyslogFacility(strFacility string) (SyslogFacility, error) {
+	if strFacility == "" {
+		return FacilityUser, nil
+	}
+	facility, ok := syslogFacilityNames[strFacility]
+	if !ok {
+		return FacilityUser, fmt.Errorf("invalid syslog facility value: %q", strFacility)
+	}
+	return facility, nil
+}
+
+// syslogSeverity maps Level to RFC 5424 severity.
+func syslogSeverity(lvl Level) int {
+	switch lvl {
+	case LogLevelTrace, LogLevelDebug:
+		return 7 // debug
+	case LogLevelInfo:
+		return 6 // informational
+	case LogLevelWarn:
+		return 4 // warning
+	case LogLevelError:
+		return 3 // error
+	case LogLevelFatal:
+		return 2 // critical
+	}
+	return 5 // notice
+}
+
+// syslogLocalPaths are tried when SyslogConfig.Network is empty.
+var syslogLocalPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+const (
+	DefaultSyslogTimeout = time.Second
+
+	// Failed connects are not retried until the backoff passes, it doubles
+	// with each failure up to the max.
+	syslogMinBackoff = 100 * time.Millisecond
+	syslogMaxBackoff = 30 * time.Second
+)
+
+type SyslogConfig struct {
+	// Network is one of "unixgram", "unix", "udp", "tcp". Empty Network
+	// means the local syslog daemon socket, Addr is ignored then.
+	Network  string
+	Addr     string
+	Format   string
+	Facility string
+	AppName  string
+	Hostname string
+	Level    string
+	// Timeout limits connect and each write, DefaultSyslogTimeout when zero.
+	Timeout time.Duration
+}
+
+// SyslogHandler sends records to syslog. Connection is established on first
+// record and re-established once per record when a write fails. After a
+// failed connect records are dropped with an error until a backoff passes,
+// so an unreachable collector does not stall logging goroutines.
+type SyslogHandler struct {
+	Level    Level
+	Facility SyslogFacility
+	Format   string
+	AppName  string
+	Hostname string
+	// Encoder renders the message part; when nil it is the message followed
+	// by fields in text layout.
+	Encoder Encoder
+
+	network string
+	addr    string
+	pid     string
+	timeout time.Duration
+
+	mu         sync.Mutex
+	conn       net.Conn
+	backoff    time.Duration
+	retryAt    time.Time
+	connectErr error
+}
+
+func NewSyslogHandler(cfg SyslogConfig) (*SyslogHandler, error) {
+	logLevel, err := ParseLogLevel(cfg.Level)
+	if err != nil {
+		return nil, fmt.Errorf("ParseLevel error: %w", err)
+	}
+	facility, err := ParseSyslogFacility(cfg.Facility)
+	if err != nil {
+		return nil, err
+	}
+	switch cfg.Format {
+	case "":
+		cfg.Format = SyslogRFC5424
+	case SyslogRFC5424, SyslogRFC3164:
+	default:
+		return nil, fmt.Errorf("invalid syslog format value: %q", cfg.Format)
+	}
+	switch cfg.Network {
+	case "", "unixgram", "unix", "udp", "tcp":
+	default:
+		return nil, fmt.Errorf("invalid syslog network value: %q", cfg.Network)
+	}
+
+	h := &SyslogHandler{
+		Level:    logLevel,
+		Facility: facility,
+		Format:   cfg.Format,
+		AppName:  cfg.AppName,
+		Hostname: cfg.Hostname,
+		network:  cfg.Network,
+		addr:     cfg.Addr,
+		pid:      strconv.Itoa(os.Getpid()),
+		timeout:  cfg.Timeout,
+	}
+	if h.timeout <= 0 {
+		h.timeout = DefaultSyslogTimeout
+	}
+	if h.AppName == "" && len(os.Args) > 0 {
+		h.AppName = filepath.Base(os.Args[0])
+	}
+	if h.Hostname == "" {
+		h.Hostname, _ = os.Hostname()
+	}
+	return h, nil
+}
+
+func (h *SyslogHandler) Enabled(level Level) bool {
+	return h.Level <= level
+}
+
+func (h *SyslogHandler) Handle(rec *Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	buf := make([]byte, 0, len(rec.Message)+128)
+	for attempt := 0; ; attempt++ {
+		if h.conn == nil {
+			if err := h.connect(); err != nil {
+				return err
+			}
+		}
+		// framing depends on the connection, so the message is built after
+		// connect
+		buf = h.appendMessage(buf[:0], rec)
+		h.conn.SetWriteDeadline(time.Now().Add(h.timeout))
+		_, err := h.conn.Write(buf)
+		if err == nil {
+			return nil
+		}
+		h.closeConn()
+		if attempt > 0 {
+			return fmt.Errorf("write to syslog: %w", err)
+		}
+	}
+}
+
+func (h *SyslogHandler) appendMessage(buf []byte, rec *Record) []byte {
+	buf = append(buf, '<')
+	buf = itoa(buf, int(h.Facility)*8+syslogSeverity(rec.Level), 1)
+	buf = append(buf, '>')
+
+	if h.Format == SyslogRFC3164 {
+		buf = rec.Time.AppendFormat(buf, time.Stamp)
+		buf = append(buf, ' ')
+		buf = append(buf, nilValue(h.Hostname)...)
+		buf = append(buf, ' ')
+		buf = append(buf, h.AppName...)
+		buf = append(buf, '[')
+		buf = append(buf, h.pid...)
+		buf = append(buf, "]: "...)
+	} else {
+		buf = append(buf, '1', ' ')
+		buf = rec.Time.AppendFormat(buf, "2006-01-02T15:04:05.000000Z07:00")
+		buf = append(buf, ' ')
+		buf = append(buf, nilValue(h.Hostname)...)
+		buf = append(buf, ' ')
+		buf = append(buf, nilValue(h.AppName)...)
+		buf = append(buf, ' ')
+		buf = append(buf, h.pid...)
+		buf = append(buf, " - - "...)
+	}
+
+	if h.Encoder != nil {
+		buf = h.Encoder.Encode(buf, rec)
+		if n := len(buf); n > 0 && buf[n-1] == '\n' {
+			buf = buf[:n-1]
+		}
+	} else {
+		buf = append(buf, rec.Message...)
+		buf = appendFieldsText(buf, rec.Fields)
+	}
+
+	switch {
+	case !h.isStream():
+		return buf
+	case h.Format == SyslogRFC3164:
+		return append(buf, '\n')
+	}
+	// octet counting framing of RFC 6587
+	frame := make([]byte, 0, len(buf)+11)
+	frame = itoa(frame, len(buf), 1)
+	frame = append(frame, ' ')
+	return append(frame, buf...)
+}
+
+func nilValue(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func (h *SyslogHandler) isStream() bool {
+	network := h.conn.RemoteAddr().Network()
+	return network == "tcp" || network == "unix"
+}
+
+// connect dials syslog unless the backoff after the previous failure has
+// not passed yet.
+func (h *SyslogHandler) connect() error {
+	now := time.Now()
+	if now.Before(h.retryAt) {
+		return fmt.Errorf("syslog reconnect postponed: %w", h.connectErr)
+	}
+	conn, err := h.dial()
+	if err != nil {
+		h.backoff *= 2
+		if h.backoff < syslogMinBackoff {
+			h.backoff = syslogMinBackoff
+		}
+		if h.backoff > syslogMaxBackoff {
+			h.backoff = syslogMaxBackoff
+		}
+		h.retryAt = now.Add(h.backoff)
+		h.connectErr = err
+		return err
+	}
+	h.conn = conn
+	h.backoff = 0
+	h.retryAt = time.Time{}
+	h.connectErr = nil
+	return nil
+}
+
+func (h *SyslogHandler) dial() (net.Conn, error) {
+	if h.network != "" {
+		conn, err := net.DialTimeout(h.network, h.addr, h.timeout)
+		if err != nil {
+			return nil, fmt.Errorf("connect to syslog: %w", err)
+		}
+		return conn, nil
+	}
+
+	for _, network := range []string{"unixgram", "unix"} {
+		for _, path := range syslogLocalPaths {
+			conn, err := net.DialTimeout(network, path, h.timeout)
+			if err == nil {
+				return conn, nil
+			}
+		}
+	}
+	return nil, errors.New("connect to syslog: no local syslog socket found")
+}
+
+func (h *SyslogHandler) closeConn() {
+	if h.conn != nil {
+		h.conn.Close()
+		h.conn = nil
+	}
+}
+
+func (h *SyslogHandler) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closeConn()
+	return nil
+}
@@ -0,0 +1,297 @@
+package justlog
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var syslogTestTime = time.Date(2021, time.Month(2), 1, 3, 4, 5, 9000000, time.UTC)
+
+// syslogTestListener collects messages received by an in-process syslog
+// server. Stream messages are split by framing of the format, octet counted
+// messages are read by the length prefix and collected without it.
+type syslogTestListener struct {
+	Addr     string
+	Messages chan string
+	close    func()
+}
+
+func listenSyslogPacket(t *testing.T, network, addr string) *syslogTestListener {
+	conn, err := net.ListenPacket(network, addr)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	l := &syslogTestListener{
+		Addr:     conn.LocalAddr().String(),
+		Messages: make(chan string, 16),
+		close:    func() { conn.Close() },
+	}
+	go func() {
+		buf := make([]byte, 65536)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			l.Messages <- string(buf[:n])
+		}
+	}()
+	return l
+}
+
+func listenSyslogStream(t *testing.T, network, addr string, octetCounting bool) *syslogTestListener {
+	ln, err := net.Listen(network, addr)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	l := &syslogTestListener{
+		Addr:     ln.Addr().String(),
+		Messages: make(chan string, 16),
+		close:    func() { ln.Close() },
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					if !octetCounting {
+						line, err := r.ReadString('\n')
+						if err != nil {
+							return
+						}
+						l.Messages <- line
+						continue
+					}
+					length, err := r.ReadString(' ')
+					if err != nil {
+						return
+					}
+					n, _ := strconv.Atoi(strings.TrimSpace(length))
+					msg := make([]byte, n)
+					if _, err := io.ReadFull(r, msg); err != nil {
+						return
+					}
+					l.Messages <- string(msg)
+				}
+			}(conn)
+		}
+	}()
+	return l
+}
+
+func (l *syslogTestListener) Next(t *testing.T) string {
+	select {
+	case msg := <-l.Messages:
+		return msg
+	case <-time.After(time.Second):
+		t.Error("no syslog message received")
+		return ""
+	}
+}
+
+type TestCase_SyslogHandler struct {
+	Config       SyslogConfig
+	Listener     func(t *testing.T) *syslogTestListener
+	Records      []*Record
+	WantMessages []string
+}
+
+func (tc TestCase_SyslogHandler) Run(t *testing.T) {
+	l := tc.Listener(t)
+	defer l.close()
+
+	cfg := tc.Config
+	cfg.Addr = l.Addr
+	h, err := NewSyslogHandler(cfg)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer h.Close()
+
+	for _, rec := range tc.Records {
+		assert.NoError(t, h.Handle(rec))
+	}
+	pid := strconv.Itoa(os.Getpid())
+	for _, want := range tc.WantMessages {
+		assert.Equal(t, strings.ReplaceAll(want, "PID", pid), l.Next(t))
+	}
+}
+
+func Test_SyslogHandler_RFC5424_UDP(t *testing.T) {
+	TestCase_SyslogHandler{
+		Config: SyslogConfig{Network: "udp", Facility: "local0", AppName: "app", Hostname: "host"},
+		Listener: func(t *testing.T) *syslogTestListener {
+			return listenSyslogPacket(t, "udp", "127.0.0.1:0")
+		},
+		Records: []*Record{
+			{Time: syslogTestTime, Level: LogLevelInfo, Message: []byte("hello"), Fields: []Field{{Key: "k", Value: 1}}},
+			{Time: syslogTestTime, Level: LogLevelFatal, Message: []byte("dead")},
+		},
+		WantMessages: []string{
+			"<134>1 2021-02-01T03:04:05.009000Z host app PID - - hello k=1",
+			"<130>1 2021-02-01T03:04:05.009000Z host app PID - - dead",
+		},
+	}.Run(t)
+}
+
+func Test_SyslogHandler_RFC3164_Unixgram(t *testing.T) {
+	TestCase_SyslogHandler{
+		Config: SyslogConfig{Network: "unixgram", Format: SyslogRFC3164, Facility: "daemon", AppName: "app", Hostname: "host"},
+		Listener: func(t *testing.T) *syslogTestListener {
+			return listenSyslogPacket(t, "unixgram", filepath.Join(t.TempDir(), "log.sock"))
+		},
+		Records: []*Record{
+			{Time: syslogTestTime, Level: LogLevelWarn, Message: []byte("careful")},
+			{Time: syslogTestTime, Level: LogLevelDebug, Message: []byte("details")},
+		},
+		WantMessages: []string{
+			"<28>Feb  1 03:04:05 host app[PID]: careful",
+			"<31>Feb  1 03:04:05 host app[PID]: details",
+		},
+	}.Run(t)
+}
+
+func Test_SyslogHandler_RFC5424_TCP_OctetCounting(t *testing.T) {
+	TestCase_SyslogHandler{
+		Config: SyslogConfig{Network: "tcp", AppName: "app", Hostname: "host"},
+		Listener: func(t *testing.T) *syslogTestListener {
+			return listenSyslogStream(t, "tcp", "127.0.0.1:0", true)
+		},
+		Records: []*Record{
+			{Time: syslogTestTime, Level: LogLevelError, Message: []byte("two words")},
+			{Time: syslogTestTime, Level: LogLevelWarn, Message: []byte("next")},
+		},
+		WantMessages: []string{
+			"<11>1 2021-02-01T03:04:05.009000Z host app PID - - two words",
+			"<12>1 2021-02-01T03:04:05.009000Z host app PID - - next",
+		},
+	}.Run(t)
+}
+
+func Test_SyslogHandler_RFC3164_Unix(t *testing.T) {
+	TestCase_SyslogHandler{
+		Config: SyslogConfig{Network: "unix", Format: SyslogRFC3164, AppName: "app", Hostname: "host"},
+		Listener: func(t *testing.T) *syslogTestListener {
+			return listenSyslogStream(t, "unix", filepath.Join(t.TempDir(), "log.sock"), false)
+		},
+		Records: []*Record{
+			{Time: syslogTestTime, Level: LogLevelInfo, Message: []byte("line")},
+		},
+		WantMessages: []string{
+			"<14>Feb  1 03:04:05 host app[PID]: line\n",
+		},
+	}.Run(t)
+}
+
+func Test_SyslogHandler_Reconnect(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	l := listenSyslogPacket(t, "unixgram", path)
+
+	h, err := NewSyslogHandler(SyslogConfig{Network: "unixgram", Addr: path, AppName: "app", Hostname: "host"})
+	assert.NoError(t, err)
+	defer h.Close()
+
+	assert.NoError(t, h.Handle(&Record{Time: syslogTestTime, Level: LogLevelInfo, Message: []byte("first")}))
+	assert.Contains(t, l.Next(t), "first")
+
+	// syslog daemon restart: socket is gone and created again
+	l.close()
+	os.Remove(path)
+	l = listenSyslogPacket(t, "unixgram", path)
+	defer l.close()
+
+	assert.NoError(t, h.Handle(&Record{Time: syslogTestTime, Level: LogLevelInfo, Message: []byte("second")}))
+	assert.Contains(t, l.Next(t), "second")
+}
+
+func Test_SyslogHandler_ReconnectBackoff(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	h, err := NewSyslogHandler(SyslogConfig{Network: "unixgram", Addr: path, AppName: "app", Hostname: "host"})
+	assert.NoError(t, err)
+	defer h.Close()
+
+	rec := &Record{Time: syslogTestTime, Level: LogLevelInfo, Message: []byte("lost")}
+	err = h.Handle(rec)
+	assert.Error(t, err)
+	assert.Equal(t, syslogMinBackoff, h.backoff)
+
+	// each failed attempt doubles the backoff up to the cap
+	for _, want := range []time.Duration{
+		200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond,
+		1600 * time.Millisecond, 3200 * time.Millisecond, 6400 * time.Millisecond,
+		12800 * time.Millisecond, 25600 * time.Millisecond, syslogMaxBackoff, syslogMaxBackoff,
+	} {
+		h.retryAt = time.Time{}
+		assert.Error(t, h.Handle(rec))
+		assert.Equal(t, want, h.backoff)
+	}
+
+	l := listenSyslogPacket(t, "unixgram", path)
+	defer l.close()
+	err = h.Handle(rec)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "syslog reconnect postponed")
+
+	h.retryAt = time.Time{}
+	assert.NoError(t, h.Handle(&Record{Time: syslogTestTime, Level: LogLevelInfo, Message: []byte("sent")}))
+	assert.Contains(t, l.Next(t), "sent")
+	assert.Equal(t, time.Duration(0), h.backoff)
+}
+
+func Test_SyslogHandler_WithHandlerBasedLogger(t *testing.T) {
+	l := listenSyslogPacket(t, "udp", "127.0.0.1:0")
+	defer l.close()
+
+	h, err := NewSyslogHandler(SyslogConfig{Network: "udp", Addr: l.Addr, Level: "warn", AppName: "app", Hostname: "host"})
+	assert.NoError(t, err)
+	defer h.Close()
+	h.Encoder = NewLogfmtEncoder(&LoggerConfig{ShowNoTime: true}, DeltaGlobal)
+
+	logger := NewHandlerBasedLogger(h)
+	logger.Info("filtered")
+	logger.WithField("user", "joe").Errorf("failed %d times", 3)
+
+	assert.Regexp(t, `^<11>1 \S+ host app \d+ - - delta=\d+\.\d{6} level=error msg="failed 3 times" user=joe$`, l.Next(t))
+}
+
+func Test_NewSyslogHandler_InvalidConfig(t *testing.T) {
+	for _, cfg := range []SyslogConfig{
+		{Facility: "local9"},
+		{Format: "rfc0000"},
+		{Network: "ip"},
+		{Level: "loud"},
+	} {
+		_, err := NewSyslogHandler(cfg)
+		assert.Error(t, err, "%+v", cfg)
+	}
+}
+
+func Test_SyslogHandler_LocalSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	l := listenSyslogPacket(t, "unixgram", path)
+	defer l.close()
+
+	defer func(paths []string) { syslogLocalPaths = paths }(syslogLocalPaths)
+	syslogLocalPaths = []string{filepath.Join(t.TempDir(), "missing.sock"), path}
+
+	h, err := NewSyslogHandler(SyslogConfig{AppName: "app", Hostname: "host"})
+	assert.NoError(t, err)
+	defer h.Close()
+
+	assert.NoError(t, h.Handle(&Record{Time: syslogTestTime, Level: LogLevelInfo, Message: []byte("local")}))
+	assert.Equal(t, "<14>1 2021-02-01T03:04:05.009000Z host app "+strconv.Itoa(os.Getpid())+" - - local", l.Next(t))
+}
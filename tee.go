@@ -0,0 +1,189 @@
+package justlog
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Sink is one output of TeeHandler. Records of Level and above are encoded
+// with Encoder and written to Out, or passed to Handler when it is set.
+type Sink struct {
+	Out     io.Writer
+	Level   Level
+	Encoder Encoder
+	Handler Handler
+	// QueueSize above zero puts Out behind AsyncWriter dropping newest lines
+	// when full, so a stuck output does not block the others. Dropped lines
+	// are counted in a warning line written to Out and passed to ErrorFunc.
+	// Without a queue Out is written by Handle, and a blocked Out blocks
+	// Handle and all the sinks after it.
+	QueueSize int
+}
+
+type teeSink struct {
+	Sink
+	mu    sync.Mutex
+	async *AsyncWriter
+}
+
+// TeeHandler sends each record to all sinks accepting its level. Sinks with
+// the same Encoder share the encoded line. Delta is the time since the
+// previous record passed to any sink, or since the previous record of the
+// same Flow.
+//
+// A failing sink does not stop the others: errors are passed to ErrorFunc
+// and the first one is returned from Handle. Handler sinks get their own copy
+// of the record, so changes they make are not seen by the other sinks.
+//
+// Use NewHandlerBasedLogger(tee) to log through it.
+type TeeHandler struct {
+	ErrorFunc func(sink int, err error)
+
+	sinks []*teeSink
+
+	delta DeltaTracker
+}
+
+func NewTeeHandler(sinks ...Sink) (*TeeHandler, error) {
+	tee := &TeeHandler{sinks: make([]*teeSink, 0, len(sinks))}
+	for i, sink := range sinks {
+		if sink.Handler == nil && sink.Out == nil {
+			return nil, fmt.Errorf("sink %d: neither Out nor Handler set", i)
+		}
+		if sink.Handler == nil && sink.Encoder == nil {
+			sink.Encoder = NewTextEncoder(nil, DeltaGlobal)
+		}
+		s := &teeSink{Sink: sink}
+		if sink.Handler == nil && sink.QueueSize > 0 {
+			s.async = NewAsyncWriter(sink.Out, sink.QueueSize, DropPolicyNewest, 0)
+			s.async.DropReport = tee.dropReport(i, sink.Encoder)
+			s.Out = s.async
+		}
+		tee.sinks = append(tee.sinks, s)
+	}
+	return tee, nil
+}
+
+// dropReport returns DropReport of the queue of sink i. It is called from the
+// queue goroutine.
+func (tee *TeeHandler) dropReport(i int, encoder Encoder) func(dropped uint64) []byte {
+	return func(dropped uint64) []byte {
+		if tee.ErrorFunc != nil {
+			tee.ErrorFunc(i, fmt.Errorf("sink %d: queue full, dropped %d lines", i, dropped))
+		}
+		rec := Record{
+			Time:    time.Now(),
+			Level:   LogLevelWarn,
+			Message: []byte("async log queue full, dropped " + strconv.FormatUint(dropped, 10) + " messages"),
+		}
+		return encoder.Encode(nil, &rec)
+	}
+}
+
+func (sink *teeSink) enabled(level Level) bool {
+	if sink.Level > level {
+		return false
+	}
+	return sink.Handler == nil || sink.Handler.Enabled(level)
+}
+
+func (tee *TeeHandler) Enabled(level Level) bool {
+	for _, sink := range tee.sinks {
+		if sink.enabled(level) {
+			return true
+		}
+	}
+	return false
+}
+
+// teeEncoded caches a line encoded for one record.
+type teeEncoded struct {
+	encoder Encoder
+	line    []byte
+}
+
+func (tee *TeeHandler) Handle(rec *Record) error {
+	setRecordDelta(rec, DeltaGlobal, &tee.delta)
+
+	var cacheArray [4]teeEncoded
+	cache := cacheArray[:0]
+
+	var firstErr error
+	for i, sink := range tee.sinks {
+		if !sink.enabled(rec.Level) {
+			continue
+		}
+		var err error
+		if sink.Handler != nil {
+			sinkRec := *rec
+			err = sink.Handler.Handle(&sinkRec)
+		} else {
+			var line []byte
+			for _, encoded := range cache {
+				if encoded.encoder == sink.Encoder {
+					line = encoded.line
+					break
+				}
+			}
+			if line == nil {
+				line = sink.Encoder.Encode(make([]byte, 0, len(rec.Message)+formatOverhead), rec)
+				cache = append(cache, teeEncoded{encoder: sink.Encoder, line: line})
+			}
+			sink.mu.Lock()
+			_, err = sink.Out.Write(line)
+			sink.mu.Unlock()
+		}
+		if err != nil {
+			err = fmt.Errorf("sink %d: %w", i, err)
+			if tee.ErrorFunc != nil {
+				tee.ErrorFunc(i, err)
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// Flush waits for queued lines of sinks with QueueSize set.
+func (tee *TeeHandler) Flush() error {
+	var firstErr error
+	for _, sink := range tee.sinks {
+		if sink.async == nil {
+			continue
+		}
+		if err := sink.async.Flush(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Close closes outputs of all sinks except os.Stdout and os.Stderr, and
+// handlers implementing io.Closer.
+func (tee *TeeHandler) Close() error {
+	var firstErr error
+	for i, sink := range tee.sinks {
+		var err error
+		switch {
+		case sink.Handler != nil:
+			if closer, ok := sink.Handler.(io.Closer); ok {
+				err = closer.Close()
+			}
+		case sink.async != nil:
+			err = sink.async.Close()
+		default:
+			sink.mu.Lock()
+			err = closeOutput(sink.Out)
+			sink.mu.Unlock()
+		}
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("sink %d: %w", i, err)
+		}
+	}
+	return firstErr
+}
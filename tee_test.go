@@ -0,0 +1,165 @@
+package justlog
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingEncoder struct {
+	Encoder
+	count int
+}
+
+func (enc *countingEncoder) Encode(buf []byte, rec *Record) []byte {
+	enc.count++
+	return enc.Encoder.Encode(buf, rec)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk on fire")
+}
+
+func Test_TeeHandler_Handle(t *testing.T) {
+	text := &countingEncoder{Encoder: NewTextEncoder(&LoggerConfig{ShowNoTime: true}, DeltaGlobal)}
+	var stderr, copyOut, file strings.Builder
+	syslog := &testHandler{Level: LogLevelTrace}
+	var sinkErrs []int
+
+	tee, err := NewTeeHandler(
+		Sink{Out: &stderr, Level: LogLevelError, Encoder: text},
+		Sink{Out: failingWriter{}, Level: LogLevelTrace},
+		Sink{Out: &file, Level: LogLevelDebug, Encoder: NewJSONEncoder(&LoggerConfig{ShowNoTime: true}, DeltaGlobal)},
+		Sink{Handler: syslog, Level: LogLevelWarn},
+		Sink{Out: &copyOut, Level: LogLevelError, Encoder: text},
+	)
+	assert.NoError(t, err)
+	tee.ErrorFunc = func(sink int, err error) {
+		sinkErrs = append(sinkErrs, sink)
+	}
+
+	now := time.Date(2021, time.Month(2), 1, 3, 4, 5, 0, time.UTC)
+	for _, rec := range []Record{
+		{Time: now, Level: LogLevelTrace, Message: []byte("trace")},
+		{Time: now.Add(time.Second), Level: LogLevelDebug, Message: []byte("debug")},
+		{Time: now.Add(2 * time.Second), Level: LogLevelWarn, Message: []byte("warn")},
+		{Time: now.Add(4 * time.Second), Level: LogLevelError, Message: []byte("error")},
+	} {
+		err := tee.Handle(&rec)
+		if assert.Error(t, err) {
+			assert.Equal(t, "sink 1: disk on fire", err.Error())
+		}
+	}
+
+	assert.Equal(t, "[+2.000000] [ERR] error\n", stderr.String())
+	assert.Equal(t, stderr.String(), copyOut.String())
+	assert.Equal(t, 1, text.count, "sinks with the same encoder share the line")
+	assert.Equal(t, `{"delta":1.000000,"level":"debug","msg":"debug"}`+"\n"+
+		`{"delta":1.000000,"level":"warn","msg":"warn"}`+"\n"+
+		`{"delta":2.000000,"level":"error","msg":"error"}`+"\n", file.String())
+	assert.Equal(t, []testRecord{
+		{Level: LogLevelWarn, Message: "warn"},
+		{Level: LogLevelError, Message: "error"},
+	}, syslog.Records)
+	assert.Equal(t, []int{1, 1, 1, 1}, sinkErrs)
+}
+
+func Test_TeeHandler_Enabled(t *testing.T) {
+	tee, err := NewTeeHandler(
+		Sink{Out: &strings.Builder{}, Level: LogLevelWarn},
+		Sink{Handler: &testHandler{Level: LogLevelError}, Level: LogLevelDebug},
+	)
+	assert.NoError(t, err)
+	assert.False(t, tee.Enabled(LogLevelInfo))
+	assert.True(t, tee.Enabled(LogLevelWarn))
+}
+
+func Test_NewTeeHandler_NoOutput(t *testing.T) {
+	_, err := NewTeeHandler(Sink{Level: LogLevelInfo})
+	assert.Error(t, err)
+}
+
+func Test_TeeHandler_QueueSize_StuckSink(t *testing.T) {
+	stuck := newGateWriter()
+	var out strings.Builder
+	tee, err := NewTeeHandler(
+		Sink{Out: stuck, QueueSize: 1},
+		Sink{Out: &out},
+	)
+	assert.NoError(t, err)
+	var errMu sync.Mutex
+	var sinkErrs []string
+	tee.ErrorFunc = func(sink int, err error) {
+		errMu.Lock()
+		defer errMu.Unlock()
+		sinkErrs = append(sinkErrs, err.Error())
+	}
+	logger := NewHandlerBasedLogger(tee)
+
+	for i := 0; i < 10; i++ {
+		logger.Infof("line %d", i)
+	}
+	assert.Equal(t, 10, strings.Count(out.String(), "\n"))
+
+	close(stuck.gate)
+	assert.NoError(t, tee.Close())
+	assert.True(t, stuck.closed)
+	lines := stuck.Lines()
+	if assert.True(t, len(lines) < 11) {
+		assert.Contains(t, lines[len(lines)-1], "[WRN] async log queue full, dropped ")
+	}
+	errMu.Lock()
+	defer errMu.Unlock()
+	if assert.Len(t, sinkErrs, 1) {
+		assert.Contains(t, sinkErrs[0], "sink 0: queue full, dropped ")
+	}
+}
+
+func Test_TeeHandler_HandlerSinkKeepsRecord(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{ShowNoTime: true})
+	assert.NoError(t, err)
+	var loggerOut, out strings.Builder
+	logger.SetOutput(&loggerOut)
+	now := time.Date(2021, time.Month(2), 1, 3, 4, 5, 0, time.UTC)
+	logger.SetPrevTime(now.Add(-time.Hour))
+
+	tee, err := NewTeeHandler(
+		Sink{Handler: logger},
+		Sink{Out: &out, Encoder: &TextEncoder{ShowNoTime: true}},
+	)
+	assert.NoError(t, err)
+	assert.NoError(t, tee.Handle(&Record{Time: now, Level: LogLevelInfo, Message: []byte("first")}))
+	assert.NoError(t, tee.Handle(&Record{Time: now.Add(time.Second), Level: LogLevelInfo, Message: []byte("second")}))
+
+	assert.Equal(t, "[+3600.000000] [INF] first\n[+1.000000] [INF] second\n", loggerOut.String())
+	assert.Equal(t, "[+0.000000] [INF] first\n[+1.000000] [INF] second\n", out.String())
+}
+
+func Test_HandlerBasedLogger_Fatal_TeeFlush(t *testing.T) {
+	patches := gomonkey.NewPatches()
+	defer patches.Reset()
+
+	queued := newGateWriter()
+	var linesAtExit []string
+	patches.ApplyFunc(os.Exit, func(code int) {
+		assert.Equal(t, 1, code)
+		linesAtExit = queued.Lines()
+	})
+
+	tee, err := NewTeeHandler(Sink{Out: queued, Encoder: &TextEncoder{ShowNoTime: true, ShowNoDelta: true}, QueueSize: 10})
+	assert.NoError(t, err)
+	logger := NewHandlerBasedLogger(&LevelHandler{Level: LogLevelInfo, Handler: tee})
+	time.AfterFunc(50*time.Millisecond, func() { close(queued.gate) })
+	logger.Fatal("boom")
+
+	assert.Equal(t, []string{"[ERR][FATAL] boom\n"}, linesAtExit)
+	assert.NoError(t, tee.Close())
+}
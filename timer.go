@@ -0,0 +1,107 @@
+package justlog
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Op is an operation timed from StartOp to End or EndWith, which log its
+// duration. Level and Threshold default to OpLevel and OpThreshold of
+// LoggerConfig and may be changed before the end.
+type Op struct {
+	// Name of a nested op is prefixed with the name of its parent and "/".
+	Name  string
+	Start time.Time
+	Level Level
+	// Threshold above zero raises level of the line to Warn when the op
+	// lasts longer.
+	Threshold time.Duration
+
+	logger *FmtBasedLogger
+	ended  uint32 // accessed atomically
+}
+
+// StartOp starts op logged by logger. Ops started by a logger returned from
+// Op.Logger are nested in that op.
+func (logger *FmtBasedLogger) StartOp(name string) *Op {
+	return logger.startOp(logger.op, name)
+}
+
+func (logger *FmtBasedLogger) startOp(parent *Op, name string) *Op {
+	if parent != nil {
+		name = parent.Name + "/" + name
+	}
+	root := logger.root()
+	return &Op{
+		Name:      name,
+		Start:     time.Now(),
+		Level:     root.opLevel,
+		Threshold: root.opThreshold,
+		logger:    logger,
+	}
+}
+
+// StartOp starts op nested in op.
+func (op *Op) StartOp(name string) *Op {
+	return op.logger.startOp(op, name)
+}
+
+// Logger returns child logger tagging lines with op field, ops it starts
+// are nested in op.
+func (op *Op) Logger() *FmtBasedLogger {
+	child := op.logger.child()
+	child.fields = mergeFields(op.logger.fields, Fields{"op": op.Name})
+	child.op = op
+	return child
+}
+
+// End logs "<name> done" with the duration of op and returns it. Only the
+// first End or EndWith logs, the others return zero.
+func (op *Op) End() time.Duration {
+	return op.end(nil)
+}
+
+// EndWith is End logging "<name> failed" at Error level when err is not nil.
+func (op *Op) EndWith(err error) time.Duration {
+	return op.end(err)
+}
+
+// end logs the line of End and EndWith. Both call it directly, so the caller
+// reported for their lines is the same number of frames up.
+func (op *Op) end(err error) time.Duration {
+	now, elapsed, ok := op.stop()
+	if ok {
+		var fieldsArray [3]Field
+		level, msg, fields := op.line(fieldsArray[:0], elapsed, err)
+		op.logger.writeFields(level, now, msg, fields, fmtCallerDepth+1)
+	}
+	return elapsed
+}
+
+func (op *Op) stop() (now time.Time, elapsed time.Duration, ok bool) {
+	if !atomic.CompareAndSwapUint32(&op.ended, 0, 1) {
+		return now, 0, false
+	}
+	now = time.Now()
+	return now, now.Sub(op.Start), true
+}
+
+func (op *Op) line(fields []Field, elapsed time.Duration, err error) (Level, string, []Field) {
+	level := op.Level
+	msg := op.Name + " done"
+	fields = append(fields, Duration("duration", elapsed))
+	if op.Threshold > 0 && elapsed > op.Threshold {
+		if level < LogLevelWarn {
+			level = LogLevelWarn
+		}
+		fields = append(fields, Duration("threshold", op.Threshold))
+	}
+	if err != nil {
+		if level < LogLevelError {
+			level = LogLevelError
+		}
+		msg = op.Name + " failed"
+		fields = append(fields, Err(err))
+	}
+	return level, msg, fields
+}
@@ -0,0 +1,76 @@
+package justlog
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FmtBasedLogger_StartOp(t *testing.T) {
+	patches := gomonkey.NewPatches()
+	defer patches.Reset()
+	now := time.Date(2021, time.Month(2), 1, 3, 4, 5, 0, time.UTC)
+	patches.ApplyFunc(time.Now, func() time.Time { return now })
+
+	logger, err := NewFmtBasedLogger(LoggerConfig{ShowNoTime: true, DeltaMode: "none", OpThreshold: time.Second})
+	assert.NoError(t, err)
+	var out strings.Builder
+	logger.SetOutput(&out)
+
+	request := logger.StartOp("request")
+	now = now.Add(100 * time.Millisecond)
+	query := request.StartOp("query")
+	request.Logger().Info("querying")
+	now = now.Add(1500 * time.Millisecond)
+	assert.Equal(t, 1500*time.Millisecond, query.End())
+	assert.Equal(t, time.Duration(0), query.End())
+
+	render := request.Logger().StartOp("render")
+	render.Level = LogLevelDebug
+	assert.Equal(t, time.Duration(0), render.End())
+	assert.Equal(t, 1600*time.Millisecond, request.EndWith(errors.New("timeout")))
+
+	assert.Equal(t, strings.Join([]string{
+		"[INF] querying op=request",
+		"[WRN] request/query done duration=1.5s threshold=1s",
+		"[ERR] request failed duration=1.6s threshold=1s error=timeout",
+		"",
+	}, "\n"), out.String())
+}
+
+func Test_FmtBasedLogger_StartOp_Config(t *testing.T) {
+	_, err := NewFmtBasedLogger(LoggerConfig{OpLevel: "verbose"})
+	assert.Error(t, err)
+
+	logger, err := NewFmtBasedLogger(LoggerConfig{ShowNoTime: true, DeltaMode: "none", OpLevel: "debug", Level: "debug"})
+	assert.NoError(t, err)
+	var out strings.Builder
+	logger.SetOutput(&out)
+
+	op := logger.Named("db").WithField("pool", 1).(*FmtBasedLogger).StartOp("connect")
+	assert.Equal(t, LogLevelDebug, op.Level)
+	assert.Equal(t, time.Duration(0), op.Threshold)
+	op.EndWith(nil)
+	assert.True(t, strings.HasPrefix(out.String(), "[DBG] [db] connect done pool=1 duration="), out.String())
+}
+
+func Test_FmtBasedLogger_StartOp_ReportCaller(t *testing.T) {
+	logger, err := NewFmtBasedLogger(LoggerConfig{ShowNoTime: true, DeltaMode: "none", ReportCaller: true})
+	assert.NoError(t, err)
+	var out strings.Builder
+	logger.SetOutput(&out)
+
+	logger.StartOp("plain").End()
+	logger.StartOp("failed").EndWith(errors.New("timeout"))
+	func() {
+		defer logger.StartOp("deferred").End()
+	}()
+
+	assert.Regexp(t, `^\[INF\] timer_test\.go:\d+: plain done duration=\S+\n`+
+		`\[ERR\] timer_test\.go:\d+: failed failed duration=\S+ error=timeout\n`+
+		`\[INF\] timer_test\.go:\d+: deferred done duration=\S+\n$`, out.String())
+}